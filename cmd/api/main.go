@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
 	"html/template"
 	"log"
 	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/DmytroPI-dev/clinic-golang/internal/config"
 	"github.com/DmytroPI-dev/clinic-golang/internal/database"
 	handler "github.com/DmytroPI-dev/clinic-golang/internal/handlers"
+	"github.com/DmytroPI-dev/clinic-golang/internal/migrations"
 	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/scheduler"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
 	"github.com/DmytroPI-dev/clinic-golang/internal/utils"
 	"github.com/gin-contrib/multitemplate"
 	"github.com/gin-contrib/sessions"
@@ -20,8 +26,9 @@ import (
 )
 
 var funcMap = template.FuncMap{
-	"Title": utils.Title,
-	"Dict":  utils.Dict,
+	"Title":    utils.Title,
+	"Dict":     utils.Dict,
+	"MediaURL": storage.URL,
 }
 
 func loadTemplates() multitemplate.Renderer {
@@ -32,6 +39,10 @@ func loadTemplates() multitemplate.Renderer {
 	}
 
 	layout := adminTpl("layout.html")
+	// Fields of the extra translation languages, shared by the content forms
+	translationFields := adminTpl("translation-fields.html")
+	// Image picker of the media library, shared by the forms with images
+	mediaPicker := adminTpl("media-picker.html")
 
 	// Program
 	programForm := adminTpl("program-form.html")
@@ -45,31 +56,88 @@ func loadTemplates() multitemplate.Renderer {
 	// Users
 	usersRow := adminTpl("user-row.html")
 	usersForm := adminTpl("user-form.html")
+	// API tokens
+	tokenRow := adminTpl("token-row.html")
+	tokenForm := adminTpl("token-form.html")
+	// Appointments
+	appointmentRow := adminTpl("appointment-row.html")
+	appointmentForm := adminTpl("appointment-form.html")
+	// Specialists
+	specialistForm := adminTpl("specialist-form.html")
+	specialistRow := adminTpl("specialist-row.html")
+	// Schedules
+	openingHoursRow := adminTpl("opening-hours-row.html")
+	shiftRow := adminTpl("shift-row.html")
+	shiftForm := adminTpl("shift-form.html")
+	exceptionRow := adminTpl("exception-row.html")
+	exceptionForm := adminTpl("exception-form.html")
+	// Categories
+	categoryForm := adminTpl("category-form.html")
+	categoryRow := adminTpl("category-row.html")
+	// Media library
+	mediaForm := adminTpl("media-form.html")
+	mediaRow := adminTpl("media-row.html")
+	// Audit log
+	auditRow := adminTpl("audit-row.html")
 	// Access forbidden
 	forbidden := adminTpl("403.html")
 
 	// Configure HTML template rendering
-	renderer.AddFromFilesFuncs("programs.html", funcMap, layout, adminTpl("programs.html"), programForm, programRow)
-	renderer.AddFromFilesFuncs("prices.html", funcMap, layout, adminTpl("prices.html"), priceForm, priceRow)
-	renderer.AddFromFilesFuncs("news.html", funcMap, layout, adminTpl("news.html"), newsForm, newsRow)
+	renderer.AddFromFilesFuncs("programs.html", funcMap, layout, adminTpl("programs.html"), programForm, programRow, translationFields)
+	renderer.AddFromFilesFuncs("prices.html", funcMap, layout, adminTpl("prices.html"), priceForm, priceRow, translationFields)
+	renderer.AddFromFilesFuncs("news.html", funcMap, layout, adminTpl("news.html"), newsForm, newsRow, translationFields, mediaPicker)
 	renderer.AddFromFilesFuncs("users.html", funcMap, layout, adminTpl("users.html"), usersForm, usersRow)
+	renderer.AddFromFilesFuncs("tokens.html", funcMap, layout, adminTpl("tokens.html"), tokenForm, tokenRow)
+	renderer.AddFromFilesFuncs("appointments.html", funcMap, layout, adminTpl("appointments.html"), appointmentForm, appointmentRow)
+	renderer.AddFromFilesFuncs("specialists.html", funcMap, layout, adminTpl("specialists.html"), specialistForm, specialistRow, translationFields)
+	renderer.AddFromFilesFuncs("schedules.html", funcMap, layout, adminTpl("schedules.html"), openingHoursRow, shiftRow, shiftForm, exceptionRow, exceptionForm)
+	renderer.AddFromFilesFuncs("categories.html", funcMap, layout, adminTpl("categories.html"), categoryForm, categoryRow, translationFields)
+	renderer.AddFromFilesFuncs("media.html", funcMap, layout, adminTpl("media.html"), mediaForm, mediaRow, translationFields)
+	renderer.AddFromFilesFuncs("audit.html", funcMap, layout, adminTpl("audit.html"), auditRow)
+	renderer.AddFromFilesFuncs("trash.html", funcMap, layout, adminTpl("trash.html"))
 	renderer.AddFromFilesFuncs("403.html", funcMap, layout, forbidden)
+	renderer.AddFromFilesFuncs("two-factor.html", funcMap, layout, adminTpl("two-factor.html"))
 
 	// For HTMX partials and standalone pages
 	partials := []string{
 		"login.html",
-		"program-form.html",
+		"login-2fa.html",
+		"form-error.html",
 		"program-row.html",
-		"price-form.html",
 		"price-row.html",
-		"news-form.html",
 		"news-row.html",
 		"user-row.html",
 		"user-form.html",
+		"token-row.html",
+		"token-form.html",
+		"appointment-row.html",
+		"appointment-form.html",
+		"specialist-row.html",
+		"opening-hours-row.html",
+		"shift-row.html",
+		"shift-form.html",
+		"exception-row.html",
+		"exception-form.html",
+		"category-row.html",
+		"media-row.html",
+		"revisions.html",
+		"revision-diff.html",
 	}
 	for _, partial := range partials {
 		renderer.AddFromFilesFuncs(partial, funcMap, adminTpl(partial))
 	}
+	// Content forms also render the fields of the extra translation languages and the image picker
+	forms := []string{
+		"program-form.html",
+		"price-form.html",
+		"news-form.html",
+		"category-form.html",
+		"specialist-form.html",
+		"media-form.html",
+	}
+	for _, form := range forms {
+		renderer.AddFromFilesFuncs(form, funcMap, adminTpl(form), translationFields, mediaPicker)
+	}
 	return renderer
 }
 
@@ -83,12 +151,14 @@ type CrudHandlers struct {
 }
 
 // registerCrudRoutes registers the standard CRUD endpoints for a resource.
-func registerCrudRoutes(group *gin.RouterGroup, db *gorm.DB, handlers CrudHandlers) {
+// Read endpoints are public, mutating endpoints require an API token with write scope on the resource.
+func registerCrudRoutes(group *gin.RouterGroup, db *gorm.DB, resource string, handlers CrudHandlers) {
+	writeRequired := handler.APITokenRequired(db, resource, models.ScopeWrite)
 	group.GET("/", handlers.List(db))
 	group.GET("/:id", handlers.Get(db))
-	group.POST("/", handlers.Create(db))
-	group.PUT("/:id", handlers.Update(db))
-	group.DELETE("/:id", handlers.Delete(db))
+	group.POST("/", writeRequired, handlers.Create(db))
+	group.PUT("/:id", writeRequired, handlers.Update(db))
+	group.DELETE("/:id", writeRequired, handlers.Delete(db))
 }
 
 // AdminCrudHandlers defines a set of handlers for an admin panel resource.
@@ -111,6 +181,13 @@ func registerAdminCrudRoutes(group *gin.RouterGroup, db *gorm.DB, handlers Admin
 	group.DELETE("/:id", handlers.Delete(db))
 }
 
+// registerRevisionRoutes registers the history and restore endpoints of a resource.
+func registerRevisionRoutes[T any](group *gin.RouterGroup, db *gorm.DB, resource handler.RevisionResource) {
+	group.GET("/:id/revisions", handler.AdminListRevisions[T](db, resource))
+	group.GET("/:id/revisions/:revision", handler.AdminShowRevision[T](db, resource))
+	group.POST("/:id/revisions/:revision/restore", handler.AdminRestoreRevision[T](db, resource))
+}
+
 func main() {
 	//Load config
 	cfg, err := config.LoadConfig(".")
@@ -119,22 +196,69 @@ func main() {
 	}
 
 	//Connect to DB
-	db, err := database.DB_Connect(cfg.DB_DSN)
+	db, err := database.DB_Connect(cfg.DB_DRIVER, cfg.DB_DSN)
 	if err != nil {
 		log.Fatalf("Could not connect to database: %s", err)
 	}
 	log.Println("Successfully connected to database")
-	// Migrating data
-	log.Println("Starting DB migration....")
-	if err := db.AutoMigrate(&models.Program{}, &models.Price{}, &models.News{}, &models.User{}); err != nil {
-		log.Fatalf("migration for models.Program failed: %s", err)
+	// Enabled content languages
+	translation.Configure(cfg.LanguageList(), cfg.DefaultLanguage)
+	// Widths of the resized copies of uploaded images
+	widths, err := cfg.ImageWidthList()
+	if err != nil {
+		log.Fatalf("Could not read IMAGE_WIDTHS: %s", err)
 	}
-	log.Println("Migration successful")
+	utils.ConfigureImages(widths)
+	webpQuality, err := cfg.WebPQualityValue()
+	if err != nil {
+		log.Fatalf("Could not read WEBP_QUALITY: %s", err)
+	}
+	utils.ConfigureWebP(webpQuality)
+	utils.ConfigureImageLimits(int64(cfg.ImageMaxMB)<<20, cfg.ImageMaxMegapixels*1_000_000)
+	// Where uploads are kept
+	uploads, err := storage.New(cfg, cfg.StorageBackend)
+	if err != nil {
+		log.Fatalf("Could not set up %s storage: %s", cfg.StorageBackend, err)
+	}
+	storage.Configure(uploads)
+	// Migrating data. Migrations normally run with `go run ./cmd/clinicctl migrate up`,
+	// the server only applies them itself when MIGRATE_ON_START is set.
+	migrator := migrations.New(db)
+	if cfg.MigrateOnStart {
+		log.Println("Starting DB migration....")
+		if _, err := migrator.Up(0); err != nil {
+			log.Fatalf("migration failed: %s", err)
+		}
+		log.Println("Migration successful")
+	} else {
+		pending, err := migrator.Pending()
+		if err != nil {
+			log.Fatalf("Could not check migrations: %s", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("%d pending migrations, run `go run ./cmd/clinicctl migrate up` or set MIGRATE_ON_START=true", len(pending))
+		}
+	}
+	// Background jobs, e.g. publishing scheduled news
+	jobs := []scheduler.Job{scheduler.PublishNews}
+	if cfg.TrashRetentionDays > 0 {
+		jobs = append(jobs, scheduler.PurgeTrash(cfg.TrashRetentionDays))
+	}
+	if cfg.LoginAttemptRetentionDays > 0 {
+		jobs = append(jobs, scheduler.PurgeLoginAttempts(cfg.LoginAttemptRetentionDays))
+	}
+	scheduler.Start(context.Background(), db, jobs...)
 
 	// Creating Gin router
 	router := gin.Default()
-	// uploaded photos
-	router.Static("/uploads", "./uploads")
+	// gin believes every proxy by default, anyone could then pick the address their logins are counted by
+	if err := router.SetTrustedProxies(cfg.TrustedProxyList()); err != nil {
+		log.Fatalf("Could not read TRUSTED_PROXIES: %s", err)
+	}
+	// uploaded photos, unless they are kept in a bucket
+	if cfg.StorageBackend == "local" {
+		router.Static("/uploads", cfg.UploadsDir)
+	}
 	// Serve frontend static files from the 'frontend/static' directory under a unique path
 	router.Static("/static", "./frontend/static")
 	// Serve frontend static files from the 'web/static' directory under a unique path
@@ -152,41 +276,80 @@ func main() {
 	v1 := router.Group("/api/v1")
 	{
 		// API CRUD endpoints
-		registerCrudRoutes(v1.Group("/programs"), db, CrudHandlers{
+		registerCrudRoutes(v1.Group("/programs"), db, models.ResourcePrograms, CrudHandlers{
 			List:   handler.ListPrograms,
 			Get:    handler.GetProgram,
 			Create: handler.CreateProgram,
 			Update: handler.UpdateProgram,
 			Delete: handler.DeleteProgram,
 		})
-		registerCrudRoutes(v1.Group("/prices"), db, CrudHandlers{
+		registerCrudRoutes(v1.Group("/prices"), db, models.ResourcePrices, CrudHandlers{
 			List:   handler.ListPrices,
 			Get:    handler.GetPrice,
 			Create: handler.CreatePrice,
 			Update: handler.UpdatePrice,
 			Delete: handler.DeletePrice,
 		})
-		registerCrudRoutes(v1.Group("/news"), db, CrudHandlers{
+		registerCrudRoutes(v1.Group("/news"), db, models.ResourceNews, CrudHandlers{
 			List:   handler.ListNews,
 			Get:    handler.GetNews,
 			Create: handler.CreateNews,
 			Update: handler.UpdateNews,
 			Delete: handler.DeleteNews,
 		})
+		registerCrudRoutes(v1.Group("/categories"), db, models.ResourceCategories, CrudHandlers{
+			List:   handler.ListCategories,
+			Get:    handler.GetCategory,
+			Create: handler.CreateCategory,
+			Update: handler.UpdateCategory,
+			Delete: handler.DeleteCategory,
+		})
+		registerCrudRoutes(v1.Group("/specialists"), db, models.ResourceSpecialists, CrudHandlers{
+			List:   handler.ListSpecialists,
+			Get:    handler.GetSpecialist,
+			Create: handler.CreateSpecialist,
+			Update: handler.UpdateSpecialist,
+			Delete: handler.DeleteSpecialist,
+		})
+		// Public booking endpoints
+		v1.GET("/search", handler.Search(db))
+		v1.GET("/availability", handler.GetAvailability(db))
+		v1.POST("/appointments", handler.CreateAppointment(db))
 	}
 
 	// Admin routes
 	adminRoutes := router.Group("/admin")
+	// Every admin form, the login included, has to send the token of its session
+	adminRoutes.Use(handler.CSRFProtect())
 	{
 		// Public routes that don't require authentication
 		adminRoutes.GET("/login", handler.ShowLoginPage)
-		adminRoutes.POST("/login", handler.HandleLogin(db))
+		loginLimits := handler.LoginLimits{
+			MaxFailures: cfg.LoginMaxFailures,
+			Lockout:     time.Duration(cfg.LoginLockoutMinutes) * time.Minute,
+		}
+		adminRoutes.POST("/login", handler.HandleLogin(db, loginLimits))
+		// Second login step of the users with an authenticator app
+		adminRoutes.GET("/login/2fa", handler.ShowTwoFactorLoginPage)
+		adminRoutes.POST("/login/2fa", handler.HandleTwoFactorLogin(db, loginLimits))
 
-		// Authenticated routes
+		// Authenticated routes. Users of the TWO_FACTOR_ROLES only get past
+		// the two-factor settings once they log in with an authenticator app.
+		twoFactorRoles := cfg.TwoFactorRoleList()
 		authenticated := adminRoutes.Group("/")
-		authenticated.Use(handler.AuthRequired())
+		authenticated.Use(handler.AuthRequired(db), handler.TwoFactorRequired(twoFactorRoles...))
 		{
-			authenticated.GET("/logout", handler.HandleLogout)
+			authenticated.POST("/logout", handler.HandleLogout)
+
+			// Two-factor login settings of the logged in user
+			twoFactorGroup := authenticated.Group("/account/2fa")
+			{
+				twoFactorGroup.GET("/", handler.ShowTwoFactorPage(db, twoFactorRoles))
+				twoFactorGroup.POST("/", handler.EnableTwoFactor(db, twoFactorRoles))
+				twoFactorGroup.POST("/restart", handler.RestartTwoFactor(db))
+				twoFactorGroup.POST("/disable", handler.DisableTwoFactor(db, twoFactorRoles))
+				twoFactorGroup.POST("/recovery-codes", handler.RegenerateRecoveryCodes(db, twoFactorRoles))
+			}
 			authenticated.GET("/", func(c *gin.Context) {
 				c.Redirect(http.StatusFound, "/admin/programs")
 			})
@@ -197,11 +360,12 @@ func main() {
 			programsGroup.GET("/", handler.RoleRequired(models.Admin, models.Editor, models.Reader), handler.ShowProgramsPage(db))
 			programsGroup.Use(handler.RoleRequired(models.Admin, models.Editor))
 			{
-				programsGroup.GET("/new", handler.AdminShowNewProgramForm)
+				programsGroup.GET("/new", handler.AdminShowNewProgramForm(db))
 				programsGroup.POST("/", handler.AdminCreateNewProgram(db))
 				programsGroup.GET("/edit/:id", handler.AdminShowEditProgramForm(db))
 				programsGroup.PUT("/:id", handler.AdminUpdateProgram(db))
 				programsGroup.DELETE("/:id", handler.AdminDeleteProgram(db))
+				registerRevisionRoutes[models.Program](programsGroup, db, handler.RevisionResource{Path: "/admin/programs", Row: "program-row.html"})
 			}
 
 			// Prices: Readers can view, Editors/Admins can modify.
@@ -209,11 +373,12 @@ func main() {
 			pricesGroup.GET("/", handler.RoleRequired(models.Admin, models.Editor, models.Reader), handler.ShowPricesPage(db))
 			pricesGroup.Use(handler.RoleRequired(models.Admin, models.Editor))
 			{
-				pricesGroup.GET("/new", handler.AdminShowNewPriceForm)
+				pricesGroup.GET("/new", handler.AdminShowNewPriceForm(db))
 				pricesGroup.POST("/", handler.AdminCreateNewPrice(db))
 				pricesGroup.GET("/edit/:id", handler.AdminShowEditPriceForm(db))
 				pricesGroup.PUT("/:id", handler.AdminUpdatePrice(db))
 				pricesGroup.DELETE("/:id", handler.AdminDeletePrice(db))
+				registerRevisionRoutes[models.Price](pricesGroup, db, handler.RevisionResource{Path: "/admin/prices", Row: "price-row.html"})
 			}
 
 			// News: Readers can view, Editors/Admins can modify.
@@ -221,11 +386,60 @@ func main() {
 			newsGroup.GET("/", handler.RoleRequired(models.Admin, models.Editor, models.Reader), handler.ShowNewsPage(db))
 			newsGroup.Use(handler.RoleRequired(models.Admin, models.Editor))
 			{
-				newsGroup.GET("/new", handler.AdminShowNewsForm)
+				newsGroup.GET("/new", handler.AdminShowNewsForm(db))
 				newsGroup.POST("/", handler.AdminCreateNews(db))
 				newsGroup.GET("/edit/:id", handler.AdminShowEditNews(db))
 				newsGroup.PUT("/:id", handler.AdminUpdateNews(db))
 				newsGroup.DELETE("/:id", handler.AdminDeleteNews(db))
+				registerRevisionRoutes[models.News](newsGroup, db, handler.RevisionResource{Path: "/admin/news", Row: "news-row.html"})
+			}
+
+			// Categories: Readers can view, Editors/Admins can modify.
+			categoriesGroup := authenticated.Group("/categories")
+			categoriesGroup.GET("/", handler.RoleRequired(models.Admin, models.Editor, models.Reader), handler.ShowCategoriesPage(db))
+			categoriesGroup.Use(handler.RoleRequired(models.Admin, models.Editor))
+			{
+				categoriesGroup.GET("/new", handler.AdminShowNewCategoryForm(db))
+				categoriesGroup.POST("/", handler.AdminCreateCategory(db))
+				categoriesGroup.GET("/edit/:id", handler.AdminShowEditCategoryForm(db))
+				categoriesGroup.PUT("/:id", handler.AdminUpdateCategory(db))
+				categoriesGroup.DELETE("/:id", handler.AdminDeleteCategory(db))
+			}
+
+			// Specialists: Readers can view, Editors/Admins can modify.
+			specialistsGroup := authenticated.Group("/specialists")
+			specialistsGroup.GET("/", handler.RoleRequired(models.Admin, models.Editor, models.Reader), handler.ShowSpecialistsPage(db))
+			specialistsGroup.Use(handler.RoleRequired(models.Admin, models.Editor))
+			{
+				specialistsGroup.GET("/new", handler.AdminShowNewSpecialistForm(db))
+				specialistsGroup.POST("/", handler.AdminCreateSpecialist(db))
+				specialistsGroup.GET("/edit/:id", handler.AdminShowEditSpecialistForm(db))
+				specialistsGroup.PUT("/:id", handler.AdminUpdateSpecialist(db))
+				specialistsGroup.DELETE("/:id", handler.AdminDeleteSpecialist(db))
+			}
+
+			// Schedules: Readers can view, Editors/Admins can modify.
+			schedulesGroup := authenticated.Group("/schedules")
+			schedulesGroup.GET("/", handler.RoleRequired(models.Admin, models.Editor, models.Reader), handler.ShowSchedulesPage(db))
+			schedulesGroup.Use(handler.RoleRequired(models.Admin, models.Editor))
+			{
+				schedulesGroup.PUT("/hours/:weekday", handler.AdminUpdateOpeningHours(db))
+				schedulesGroup.GET("/shifts/new", handler.AdminShowNewShiftForm(db))
+				schedulesGroup.POST("/shifts", handler.AdminCreateShift(db))
+				schedulesGroup.DELETE("/shifts/:id", handler.AdminDeleteShift(db))
+				schedulesGroup.GET("/exceptions/new", handler.AdminShowNewExceptionForm(db))
+				schedulesGroup.POST("/exceptions", handler.AdminCreateException(db))
+				schedulesGroup.DELETE("/exceptions/:id", handler.AdminDeleteException(db))
+			}
+
+			// Appointments: Readers can view, Editors/Admins can confirm, reschedule or cancel.
+			appointmentsGroup := authenticated.Group("/appointments")
+			appointmentsGroup.GET("/", handler.RoleRequired(models.Admin, models.Editor, models.Reader), handler.ShowAppointmentsPage(db))
+			appointmentsGroup.Use(handler.RoleRequired(models.Admin, models.Editor))
+			{
+				appointmentsGroup.GET("/edit/:id", handler.AdminShowEditAppointmentForm(db))
+				appointmentsGroup.PUT("/:id", handler.AdminUpdateAppointment(db))
+				appointmentsGroup.PUT("/:id/status", handler.AdminUpdateAppointmentStatus(db))
 			}
 
 			// Users: Only Admins can manage users.
@@ -239,6 +453,45 @@ func main() {
 				Update:       handler.AdminUpdateUser,
 				Delete:       handler.AdminDeleteUser,
 			})
+			usersGroup.PUT("/:id/unlock", handler.AdminUnlockUser(db))
+
+			// API tokens: Only Admins can issue and revoke tokens.
+			tokensGroup := authenticated.Group("/tokens")
+			tokensGroup.Use(handler.RoleRequired(models.Admin))
+			{
+				tokensGroup.GET("/", handler.ShowTokensPage(db))
+				tokensGroup.GET("/new", handler.AdminShowNewTokenForm)
+				tokensGroup.POST("/", handler.AdminCreateToken(db))
+				tokensGroup.DELETE("/:id", handler.AdminRevokeToken(db))
+			}
+
+			// Media library: Everyone can browse it, only Admins and Editors upload and delete images.
+			mediaGroup := authenticated.Group("/media")
+			mediaGroup.GET("/", handler.RoleRequired(models.Admin, models.Editor, models.Reader), handler.ShowMediaPage(db))
+			mediaGroup.Use(handler.RoleRequired(models.Admin, models.Editor))
+			{
+				mediaGroup.GET("/new", handler.AdminShowMediaForm(db))
+				mediaGroup.POST("/", handler.AdminCreateMedia(db))
+				mediaGroup.GET("/edit/:id", handler.AdminShowEditMedia(db))
+				mediaGroup.PUT("/:id", handler.AdminUpdateMedia(db))
+				mediaGroup.DELETE("/:id", handler.AdminDeleteMedia(db))
+			}
+
+			// Trash: Admins and Editors can restore deleted content, only Admins delete it for good.
+			trashGroup := authenticated.Group("/trash")
+			trashGroup.Use(handler.RoleRequired(models.Admin, models.Editor))
+			{
+				trashGroup.GET("/", handler.ShowTrashPage(db, cfg.TrashRetentionDays))
+				trashGroup.POST("/:type/:id/restore", handler.AdminRestoreTrash(db))
+				trashGroup.DELETE("/:type/:id", handler.RoleRequired(models.Admin), handler.AdminPurgeTrash(db))
+			}
+
+			// Audit log: Only Admins can see who changed what.
+			auditGroup := authenticated.Group("/audit")
+			auditGroup.Use(handler.RoleRequired(models.Admin))
+			{
+				auditGroup.GET("/", handler.ShowAuditPage(db))
+			}
 		}
 
 		//Testing route
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// contentExport is the file format of content export and import.
+// Records keep their IDs, so links between them survive the round trip.
+type contentExport struct {
+	ExportedAt   time.Time
+	Categories   []models.Category
+	Programs     []models.Program
+	Prices       []models.Price
+	News         []models.News
+	Specialists  []models.Specialist
+	Translations []models.Translation
+}
+
+// runContent exports the site content to a JSON file or imports it back.
+// Importing updates records with the same ID and adds the others.
+func runContent(args []string) {
+	name, args := subcommand(args, contentUsage)
+	flags := flag.NewFlagSet("content "+name, flag.ExitOnError)
+	out := flags.String("out", "content.json", "File to export to")
+	in := flags.String("in", "content.json", "File to import from")
+	flags.Parse(args)
+
+	_, db := connect()
+	switch name {
+	case "export":
+		content := contentExport{ExportedAt: time.Now()}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, find := range []func() error{
+				func() error { return tx.Order("id").Find(&content.Categories).Error },
+				func() error { return tx.Order("id").Find(&content.Programs).Error },
+				func() error { return tx.Order("id").Find(&content.Prices).Error },
+				func() error { return tx.Order("id").Find(&content.News).Error },
+				func() error { return tx.Preload("Programs").Order("id").Find(&content.Specialists).Error },
+				func() error { return tx.Order("id").Find(&content.Translations).Error },
+			} {
+				if err := find(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("Could not read content: %s", err)
+		}
+		data, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			log.Fatalf("Could not encode content: %s", err)
+		}
+		if err := os.WriteFile(*out, data, 0o644); err != nil {
+			log.Fatalf("Could not write %s: %s", *out, err)
+		}
+		log.Printf("Exported %d categories, %d programs, %d prices, %d news, %d specialists to %s",
+			len(content.Categories), len(content.Programs), len(content.Prices), len(content.News), len(content.Specialists), *out)
+
+	case "import":
+		data, err := os.ReadFile(*in)
+		if err != nil {
+			log.Fatalf("Could not read %s: %s", *in, err)
+		}
+		var content contentExport
+		if err := json.Unmarshal(data, &content); err != nil {
+			log.Fatalf("Could not decode %s: %s", *in, err)
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// Existing records with the same ID are overwritten
+			upsert := tx.Clauses(clause.OnConflict{UpdateAll: true})
+			for _, records := range []any{&content.Categories, &content.Programs, &content.Prices, &content.News} {
+				if err := createAll(upsert, records); err != nil {
+					return err
+				}
+			}
+			// Only the links to the programs are written, not the programs again
+			if err := createAll(upsert.Omit("Programs.*"), &content.Specialists); err != nil {
+				return err
+			}
+			return createAll(tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "language"}, {Name: "field"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+			}), &content.Translations)
+		})
+		if err != nil {
+			log.Fatalf("Could not import content: %s", err)
+		}
+		log.Printf("Imported %d categories, %d programs, %d prices, %d news, %d specialists from %s",
+			len(content.Categories), len(content.Programs), len(content.Prices), len(content.News), len(content.Specialists), *in)
+
+	default:
+		log.Fatalf("Unknown content command %q, use %s", name, contentUsage)
+	}
+}
+
+// createAll inserts a pointer to a slice of records, doing nothing for an empty slice.
+func createAll(tx *gorm.DB, records any) error {
+	if reflect.ValueOf(records).Elem().Len() == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(records, 100).Error
+}
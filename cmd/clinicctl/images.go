@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/media"
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+	"github.com/DmytroPI-dev/clinic-golang/internal/utils"
+)
+
+// runImages makes the variants of the uploaded images again, or adds the untracked ones to the media library.
+func runImages(args []string) {
+	name, _ := subcommand(args, imagesUsage)
+	switch name {
+	case "regenerate":
+		regenerateImages()
+	case "index":
+		indexImages()
+	default:
+		log.Fatalf("Unknown images command %q, use %s", name, imagesUsage)
+	}
+}
+
+// regenerateImages makes the variants of the media library images again,
+// e.g. after IMAGE_WIDTHS changed.
+func regenerateImages() {
+	_, db := connect()
+	var assets []models.MediaAsset
+	if err := db.Find(&assets).Error; err != nil {
+		log.Fatalf("Could not list media library: %s", err)
+	}
+	var failed int
+	for i := range assets {
+		if err := media.Regenerate(db, &assets[i]); err != nil {
+			// A missing file should not stop the others
+			log.Printf("Skipping %s: %s", assets[i].Path, err)
+			failed++
+		}
+	}
+	log.Printf("Regenerated %d images, failed %d", len(assets)-failed, failed)
+}
+
+// indexImages adds the uploaded files missing from the media library,
+// e.g. the ones uploaded before it existed, and makes their variants.
+func indexImages() {
+	_, db := connect()
+	files, err := storage.Current().List()
+	if err != nil {
+		log.Fatalf("Could not list uploads: %s", err)
+	}
+	// Variants are files of the media library too
+	known := map[string]bool{}
+	for _, model := range []any{&models.MediaAsset{}, &models.MediaVariant{}} {
+		var tracked []string
+		if err := db.Model(model).Pluck("path", &tracked).Error; err != nil {
+			log.Fatalf("Could not list media library: %s", err)
+		}
+		for _, path := range tracked {
+			known[path] = true
+		}
+	}
+	var added, failed int
+	for _, file := range files {
+		path := storage.Path(file)
+		if known[path] {
+			continue
+		}
+		info, err := utils.ImageInfo(path)
+		if err != nil {
+			log.Printf("Skipping %s: %s", path, err)
+			failed++
+			continue
+		}
+		asset := models.MediaAsset{
+			Path:         info.Path,
+			OriginalName: file,
+			Width:        info.Width,
+			Height:       info.Height,
+			Size:         info.Size,
+			// Nothing tells how the file came, so it is kept like the ones uploaded to the library
+			Pinned: true,
+		}
+		if err := db.Create(&asset).Error; err != nil {
+			log.Fatalf("Could not add %s: %s", path, err)
+		}
+		if err := media.Regenerate(db, &asset); err != nil {
+			log.Printf("Could not make the variants of %s: %s", path, err)
+		}
+		added++
+	}
+	log.Printf("Added %d images to the media library, skipped %d", added, failed)
+}
@@ -0,0 +1,113 @@
+// clinicctl is the management command of the clinic backend.
+//
+// usage: go run ./cmd/clinicctl <command> [subcommand] [flags]
+//
+//	user create -user NAME -password PASS -email EMAIL [-role admin|editor|reader]
+//	user list
+//	user disable -user NAME [-enable]
+//	user password -user NAME -password PASS
+//	user role -user NAME -role admin|editor|reader
+//	user reset-2fa -user NAME
+//	migrate up [n] | down [n] | status | create <name>
+//	seed [-file dummy_dataset.sql]
+//	content export [-out content.json]
+//	content import [-in content.json]
+//	images regenerate | index
+//	storage migrate [-from local] [-to s3] [-keep]
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/config"
+	"github.com/DmytroPI-dev/clinic-golang/internal/database"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+	"github.com/DmytroPI-dev/clinic-golang/internal/utils"
+	"gorm.io/gorm"
+)
+
+// command is a top-level clinicctl command, it gets the arguments after its name.
+type command struct {
+	usage string
+	run   func(args []string)
+}
+
+// Usage lines of the commands
+const (
+	userUsage    = "user create|list|disable|password|role|reset-2fa [flags]"
+	migrateUsage = "migrate up [n] | down [n] | status | create <name>"
+	seedUsage    = "seed [-file dummy_dataset.sql]"
+	contentUsage = "content export [-out file] | import [-in file]"
+	imagesUsage  = "images regenerate | index"
+	storageUsage = "storage migrate [-from local|s3] [-to local|s3] [-keep]"
+)
+
+var commands = map[string]command{
+	"user":    {usage: userUsage, run: runUser},
+	"migrate": {usage: migrateUsage, run: runMigrate},
+	"seed":    {usage: seedUsage, run: runSeed},
+	"content": {usage: contentUsage, run: runContent},
+	"images":  {usage: imagesUsage, run: runImages},
+	"storage": {usage: storageUsage, run: runStorage},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	cmd.run(os.Args[2:])
+}
+
+// usage prints the available commands and exits.
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: clinicctl <command> [arguments]")
+	for _, name := range []string{"user", "migrate", "seed", "content", "images", "storage"} {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	os.Exit(2)
+}
+
+// connect loads the config and connects to the configured database.
+func connect() (config.Config, *gorm.DB) {
+	// Loading config
+	cfg, err := config.LoadConfig(".")
+	if err != nil {
+		log.Fatalf("Could not load environment variables: %s", err)
+	}
+	// Connect to DB
+	db, err := database.DB_Connect(cfg.DB_DRIVER, cfg.DB_DSN)
+	if err != nil {
+		log.Fatalf("Could not connect to database: %s", err)
+	}
+	widths, err := cfg.ImageWidthList()
+	if err != nil {
+		log.Fatalf("Could not read IMAGE_WIDTHS: %s", err)
+	}
+	utils.ConfigureImages(widths)
+	webpQuality, err := cfg.WebPQualityValue()
+	if err != nil {
+		log.Fatalf("Could not read WEBP_QUALITY: %s", err)
+	}
+	utils.ConfigureWebP(webpQuality)
+	uploads, err := storage.New(cfg, cfg.StorageBackend)
+	if err != nil {
+		log.Fatalf("Could not set up %s storage: %s", cfg.StorageBackend, err)
+	}
+	storage.Configure(uploads)
+	return cfg, db
+}
+
+// subcommand splits the subcommand name from its arguments.
+func subcommand(args []string, usage string) (string, []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: clinicctl %s\n", usage)
+		os.Exit(2)
+	}
+	return args[0], args[1:]
+}
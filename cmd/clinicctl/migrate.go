@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/migrations"
+)
+
+// runMigrate applies, rolls back, lists and creates migrations.
+//
+//	migrate up [n]        apply all or the next n pending migrations
+//	migrate down [n]      roll back the last n migrations, 1 by default
+//	migrate status        list migrations and when they were applied
+//	migrate create <name> add an empty migration to internal/migrations
+func runMigrate(args []string) {
+	name, args := subcommand(args, migrateUsage)
+	flags := flag.NewFlagSet("migrate "+name, flag.ExitOnError)
+	dir := flags.String("dir", "internal/migrations", "Directory of the migration files, used by create")
+	flags.Parse(args)
+	args = flags.Args()
+
+	// create only writes a file, no database is needed
+	if name == "create" {
+		if len(args) != 1 {
+			log.Fatal("create needs the migration name, e.g. create add_news_slug")
+		}
+		path, err := migrations.Create(*dir, args[0])
+		if err != nil {
+			log.Fatalf("Could not create migration: %s", err)
+		}
+		log.Printf("Created %s", path)
+		return
+	}
+
+	_, db := connect()
+	migrator := migrations.New(db)
+	switch name {
+	case "up":
+		done, err := migrator.Up(steps(args, 0))
+		for _, migration := range done {
+			log.Printf("Applied %04d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %s", err)
+		}
+		if len(done) == 0 {
+			log.Println("No pending migrations")
+		}
+	case "down":
+		done, err := migrator.Down(steps(args, 1))
+		for _, migration := range done {
+			log.Printf("Rolled back %04d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("Rollback failed: %s", err)
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("Could not read migrations: %s", err)
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, applied)
+		}
+	default:
+		log.Fatalf("Unknown migrate command %q, use %s", name, migrateUsage)
+	}
+}
+
+// steps reads the optional number of migrations to run.
+func steps(args []string, fallback int) int {
+	if len(args) == 0 {
+		return fallback
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		log.Fatalf("Invalid number of migrations %q", args[0])
+	}
+	return n
+}
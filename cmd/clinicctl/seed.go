@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/database"
+	"gorm.io/gorm"
+)
+
+// runSeed loads a SQL file with sample data, e.g. dummy_dataset.sql, in one transaction.
+// The file is written for MySQL. On other databases backtick quoting and NOW()
+// are translated, so the sample data loads into SQLite and PostgreSQL too.
+func runSeed(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	file := flags.String("file", "dummy_dataset.sql", "SQL file to load")
+	flags.Parse(args)
+
+	content, err := os.ReadFile(*file)
+	if err != nil {
+		log.Fatalf("Could not read %s: %s", *file, err)
+	}
+	_, db := connect()
+	statements := splitSQL(string(content), db.Dialector.Name() != database.DriverMySQL)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Could not load %s: %s", *file, err)
+	}
+	log.Printf("Loaded %d statements from %s", len(statements), *file)
+}
+
+// splitSQL splits a SQL script into statements, skipping "--" comments.
+// Semicolons and comment markers inside quoted strings are kept.
+// With portable set, `name` becomes "name" and NOW() becomes CURRENT_TIMESTAMP.
+func splitSQL(script string, portable bool) []string {
+	var statements []string
+	var current strings.Builder
+	inString := false
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case inString:
+			current.WriteByte(c)
+			if c == '\\' && i+1 < len(script) {
+				i++
+				current.WriteByte(script[i])
+			} else if c == '\'' {
+				inString = false
+			}
+		case c == '\'':
+			inString = true
+			current.WriteByte(c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			// Skip to the end of the line
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == ';':
+			if statement := strings.TrimSpace(current.String()); statement != "" {
+				statements = append(statements, statement)
+			}
+			current.Reset()
+		case portable && c == '`':
+			current.WriteByte('"')
+		case portable && strings.HasPrefix(script[i:], "NOW()"):
+			current.WriteString("CURRENT_TIMESTAMP")
+			i += len("NOW()") - 1
+		default:
+			current.WriteByte(c)
+		}
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"log"
+	"mime"
+	"path/filepath"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/config"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+)
+
+// runStorage moves the uploaded files between storage backends, e.g. from the local
+// uploads directory to an S3 bucket before switching STORAGE_BACKEND to s3.
+// Records keep the same image paths on every backend, so they need no changes.
+func runStorage(args []string) {
+	name, args := subcommand(args, storageUsage)
+	if name != "migrate" {
+		log.Fatalf("Unknown storage command %q, use %s", name, storageUsage)
+	}
+	flags := flag.NewFlagSet("storage "+name, flag.ExitOnError)
+	from := flags.String("from", "local", "Backend to move the files from, local or s3")
+	to := flags.String("to", "s3", "Backend to move the files to, local or s3")
+	keep := flags.Bool("keep", false, "Copy the files, keeping them in the old backend")
+	flags.Parse(args)
+	if *from == *to {
+		log.Fatalf("Nothing to do, -from and -to are both %s", *from)
+	}
+
+	cfg, err := config.LoadConfig(".")
+	if err != nil {
+		log.Fatalf("Could not load environment variables: %s", err)
+	}
+	source, err := storage.New(cfg, *from)
+	if err != nil {
+		log.Fatalf("Could not set up %s storage: %s", *from, err)
+	}
+	target, err := storage.New(cfg, *to)
+	if err != nil {
+		log.Fatalf("Could not set up %s storage: %s", *to, err)
+	}
+	names, err := source.List()
+	if err != nil {
+		log.Fatalf("Could not list %s storage: %s", *from, err)
+	}
+	var moved, failed int
+	for _, name := range names {
+		if err := copyFile(source, target, name); err != nil {
+			// A broken file should not stop the others
+			log.Printf("Skipping %s: %s", name, err)
+			failed++
+			continue
+		}
+		if !*keep {
+			if err := source.Delete(name); err != nil {
+				log.Printf("Could not remove %s from %s storage: %s", name, *from, err)
+			}
+		}
+		moved++
+	}
+	log.Printf("Moved %d files from %s to %s storage, failed %d", moved, *from, *to, failed)
+}
+
+// copyFile copies a file from one backend to another.
+func copyFile(source, target storage.Storage, name string) error {
+	file, err := source.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// Uploads are small images, S3 wants to know the size up front
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	return target.Put(name, bytes.NewReader(content), int64(len(content)), mime.TypeByExtension(filepath.Ext(name)))
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/twofactor"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// runUser manages admin panel users.
+func runUser(args []string) {
+	name, args := subcommand(args, userUsage)
+	flags := flag.NewFlagSet("user "+name, flag.ExitOnError)
+	userName := flags.String("user", "", "User name")
+	password := flags.String("password", "", "Password")
+	email := flags.String("email", "", "Email")
+	role := flags.String("role", "", "Role: admin, editor or reader")
+	enable := flags.Bool("enable", false, "Enable the user again, used by disable")
+	flags.Parse(args)
+
+	cfg, db := connect()
+	switch name {
+	case "create":
+		if *userName == "" || *password == "" || *email == "" {
+			log.Fatal("user, password, and email are required")
+		}
+		// Without -role the user becomes a superuser
+		if *role == "" {
+			*role = cfg.AdminRole
+		}
+		checkRole(*role)
+		// Check if user exists
+		var existing models.User
+		err := db.Where("user_name = ?", *userName).First(&existing).Error
+		if err == nil {
+			log.Fatalf("User with userName '%s' already exists, try another userName!", *userName)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Fatalf("Error checking for existing user: %s", err)
+		}
+		user := models.User{
+			UserName:     *userName,
+			PasswordHash: hashPassword(*password),
+			Email:        *email,
+			Role:         *role,
+		}
+		if err := db.Create(&user).Error; err != nil {
+			log.Fatalf("Could not create user: %s", err)
+		}
+		log.Printf("User %s created with role %s", user.UserName, user.Role)
+
+	case "list":
+		var users []models.User
+		if err := db.Order("id asc").Find(&users).Error; err != nil {
+			log.Fatalf("Could not list users: %s", err)
+		}
+		out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(out, "ID\tUSER\tEMAIL\tROLE\tSTATUS")
+		for _, user := range users {
+			status := "active"
+			if user.IsDisabled() {
+				status = "disabled " + user.DisabledAt.Format("2006-01-02")
+			}
+			if user.HasTwoFactor() {
+				status += ", 2fa"
+			}
+			fmt.Fprintf(out, "%d\t%s\t%s\t%s\t%s\n", user.ID, user.UserName, user.Email, user.Role, status)
+		}
+		out.Flush()
+
+	case "disable":
+		user := findUser(db, *userName)
+		var disabledAt *time.Time
+		if !*enable {
+			now := time.Now()
+			disabledAt = &now
+		}
+		if err := db.Model(&user).Update("disabled_at", disabledAt).Error; err != nil {
+			log.Fatalf("Could not update user: %s", err)
+		}
+		if *enable {
+			log.Printf("User %s enabled", user.UserName)
+		} else {
+			log.Printf("User %s disabled", user.UserName)
+		}
+
+	case "password":
+		user := findUser(db, *userName)
+		if *password == "" {
+			log.Fatal("password is required")
+		}
+		if err := db.Model(&user).Update("password_hash", hashPassword(*password)).Error; err != nil {
+			log.Fatalf("Could not update password: %s", err)
+		}
+		log.Printf("Password of %s changed", user.UserName)
+
+	case "role":
+		user := findUser(db, *userName)
+		checkRole(*role)
+		if err := db.Model(&user).Update("role", *role).Error; err != nil {
+			log.Fatalf("Could not update role: %s", err)
+		}
+		log.Printf("User %s is now %s", user.UserName, *role)
+
+	case "reset-2fa":
+		// For a user who lost the authenticator app and the recovery codes.
+		// A role in TWO_FACTOR_ROLES sets it up again on the next login.
+		user := findUser(db, *userName)
+		if !user.HasTwoFactor() {
+			log.Fatalf("User %s does not use two-factor login", user.UserName)
+		}
+		if err := twofactor.Disable(db, &user); err != nil {
+			log.Fatalf("Could not reset two-factor login: %s", err)
+		}
+		log.Printf("Two-factor login of %s reset", user.UserName)
+
+	default:
+		log.Fatalf("Unknown user command %q, use %s", name, userUsage)
+	}
+}
+
+// findUser loads a user by name or exits.
+func findUser(db *gorm.DB, userName string) models.User {
+	if userName == "" {
+		log.Fatal("user is required")
+	}
+	var user models.User
+	if err := db.Where("user_name = ?", userName).First(&user).Error; err != nil {
+		log.Fatalf("Could not find user %s: %s", userName, err)
+	}
+	return user
+}
+
+// checkRole exits when the role is unknown.
+func checkRole(role string) {
+	if !slices.Contains(models.AllRoles, role) {
+		log.Fatalf("Unknown role %q, use one of %v", role, models.AllRoles)
+	}
+}
+
+// hashPassword hashes a password the same way the admin panel does.
+func hashPassword(password string) string {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		log.Fatalf("Could not hash password: %s", err)
+	}
+	return string(hashed)
+}
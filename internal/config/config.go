@@ -1,6 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/spf13/viper"
 )
 
@@ -12,6 +17,96 @@ type Config struct {
 	DB_DSN        string `mapstructure:"DB_DSN"`
 	AdminRole     string `mapstructure:"ADMIN_ROLE"`
 	SessionSecret string `mapstructure:"SESSION_SECRET"`
+	// Database driver: mysql (default), postgres or sqlite
+	DB_DRIVER string `mapstructure:"DB_DRIVER"`
+	// Apply pending migrations when the server starts, instead of `go run ./cmd/clinicctl migrate up`
+	MigrateOnStart bool `mapstructure:"MIGRATE_ON_START"`
+	// Comma separated list of enabled content languages, e.g. "pl,en,uk,de"
+	Languages       string `mapstructure:"LANGUAGES"`
+	DefaultLanguage string `mapstructure:"DEFAULT_LANGUAGE"`
+	// Days deleted content stays in the trash before it is purged, 0 keeps it forever
+	TrashRetentionDays int `mapstructure:"TRASH_RETENTION_DAYS"`
+	// Comma separated widths of the resized copies made of uploaded images, e.g. "160,400,800,1600"
+	ImageWidths string `mapstructure:"IMAGE_WIDTHS"`
+	// Largest accepted image upload, in megabytes and in megapixels
+	ImageMaxMB         int `mapstructure:"IMAGE_MAX_MB"`
+	ImageMaxMegapixels int `mapstructure:"IMAGE_MAX_MEGAPIXELS"`
+	// Quality of the lossy WebP copies of uploaded images, 1 to 100, where 100 is lossless
+	WebPQuality int `mapstructure:"WEBP_QUALITY"`
+	// Where uploads are kept: local (default) or s3. Instances behind a load balancer need s3
+	StorageBackend string `mapstructure:"STORAGE_BACKEND"`
+	// Directory of the local uploads, served under /uploads
+	UploadsDir string `mapstructure:"UPLOADS_DIR"`
+	// Public address prefix of the local uploads, e.g. a CDN in front of /uploads
+	UploadsURL string `mapstructure:"UPLOADS_URL"`
+	// S3 compatible bucket of the uploads, e.g. S3_ENDPOINT=localhost:9000 for MinIO
+	S3Endpoint  string `mapstructure:"S3_ENDPOINT"`
+	S3Region    string `mapstructure:"S3_REGION"`
+	S3Bucket    string `mapstructure:"S3_BUCKET"`
+	S3AccessKey string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey string `mapstructure:"S3_SECRET_KEY"`
+	S3UseSSL    bool   `mapstructure:"S3_USE_SSL"`
+	// Public address prefix of the bucket, e.g. a CDN. Defaults to the bucket on S3_ENDPOINT
+	S3PublicURL string `mapstructure:"S3_PUBLIC_URL"`
+	// Wrong passwords in a row that lock an admin account, 0 never locks, and for how many minutes
+	LoginMaxFailures    int `mapstructure:"LOGIN_MAX_FAILURES"`
+	LoginLockoutMinutes int `mapstructure:"LOGIN_LOCKOUT_MINUTES"`
+	// Days failed logins are kept before they are purged, 0 keeps them forever
+	LoginAttemptRetentionDays int `mapstructure:"LOGIN_ATTEMPT_RETENTION_DAYS"`
+	// Comma separated addresses or CIDR ranges of the proxies in front of the server, e.g. the
+	// load balancer. Only they are believed about the client address, which failed logins are counted by
+	TrustedProxies string `mapstructure:"TRUSTED_PROXIES"`
+	// Comma separated roles that have to log in with an authenticator app, e.g. "admin,editor".
+	// Users of the other roles may still turn two-factor login on for themselves
+	TwoFactorRoles string `mapstructure:"TWO_FACTOR_ROLES"`
+}
+
+// ImageWidthList returns the widths of the resized copies of uploaded images, smallest first.
+func (c Config) ImageWidthList() ([]int, error) {
+	var widths []int
+	for _, value := range strings.Split(c.ImageWidths, ",") {
+		width, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || width <= 0 {
+			return nil, fmt.Errorf("invalid image width %q", value)
+		}
+		widths = append(widths, width)
+	}
+	sort.Ints(widths)
+	return widths, nil
+}
+
+// WebPQualityValue returns the quality of the WebP copies of uploaded images.
+func (c Config) WebPQualityValue() (int, error) {
+	if c.WebPQuality < 1 || c.WebPQuality > 100 {
+		return 0, fmt.Errorf("invalid WebP quality %d, use 1 to 100", c.WebPQuality)
+	}
+	return c.WebPQuality, nil
+}
+
+// TrustedProxyList returns the proxies trusted to forward the client address, none by default.
+func (c Config) TrustedProxyList() []string {
+	return splitList(c.TrustedProxies)
+}
+
+// TwoFactorRoleList returns the roles that have to log in with an authenticator app.
+func (c Config) TwoFactorRoleList() []string {
+	return splitList(c.TwoFactorRoles)
+}
+
+// splitList splits a comma separated setting, an empty one gives no values.
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// LanguageList returns the enabled content languages.
+func (c Config) LanguageList() []string {
+	return strings.Split(c.Languages, ",")
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -19,6 +114,23 @@ func LoadConfig(path string) (config Config, err error) {
 	viper.SetConfigName(".env")
 	viper.SetConfigType("env")
 	viper.AutomaticEnv()
+	viper.SetDefault("DB_DRIVER", "mysql")
+	viper.SetDefault("LANGUAGES", "pl,en,uk")
+	viper.SetDefault("DEFAULT_LANGUAGE", "pl")
+	viper.SetDefault("TRASH_RETENTION_DAYS", 30)
+	viper.SetDefault("IMAGE_WIDTHS", "160,400,800,1600")
+	viper.SetDefault("IMAGE_MAX_MB", 10)
+	viper.SetDefault("IMAGE_MAX_MEGAPIXELS", 40)
+	viper.SetDefault("WEBP_QUALITY", 80)
+	viper.SetDefault("STORAGE_BACKEND", "local")
+	viper.SetDefault("UPLOADS_DIR", "uploads")
+	viper.SetDefault("UPLOADS_URL", "/uploads")
+	viper.SetDefault("S3_USE_SSL", true)
+	viper.SetDefault("LOGIN_MAX_FAILURES", 5)
+	viper.SetDefault("LOGIN_LOCKOUT_MINUTES", 15)
+	viper.SetDefault("LOGIN_ATTEMPT_RETENTION_DAYS", 30)
+	viper.SetDefault("TRUSTED_PROXIES", "")
+	viper.SetDefault("TWO_FACTOR_ROLES", "admin")
 	err = viper.ReadInConfig()
 	if err != nil {
 		return
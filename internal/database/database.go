@@ -1,14 +1,93 @@
 package database
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func DB_Connect(dsn string) (*gorm.DB, error) {
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+// Supported values of the DB_DRIVER setting.
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// DB_Connect opens the database with the given driver. An empty driver means MySQL.
+// For SQLite the DSN is a file name, or ":memory:" for a throwaway database.
+func DB_Connect(driver, dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch strings.ToLower(driver) {
+	case DriverMySQL, "":
+		dialector = mysql.Open(dsn)
+	case DriverPostgres:
+		dialector = postgres.Open(dsn)
+	case DriverSQLite:
+		dialector = sqlite.Open(sqliteDSN(dsn))
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q, use %s, %s or %s", driver, DriverMySQL, DriverPostgres, DriverSQLite)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
+	if db.Dialector.Name() == DriverSQLite {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		// SQLite allows a single writer, and every connection to ":memory:" is a new database
+		sqlDB.SetMaxOpenConns(1)
+	}
 	return db, nil
 }
+
+// sqliteDSN turns on foreign keys and waits for locks instead of failing with "database is locked".
+func sqliteDSN(dsn string) string {
+	if dsn == "" {
+		dsn = "clinic.db"
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
+
+// Like returns a case-insensitive "contains" condition on a column, to be used
+// with a term escaped by EscapeLike, e.g. db.Where(Like(db, "title"), "%"+EscapeLike(term)+"%").
+// MySQL and SQLite compare case-insensitively already, PostgreSQL needs ILIKE.
+// SQLite has no default escape character, so "!" is set explicitly.
+func Like(db *gorm.DB, column string) string {
+	operator := "LIKE"
+	if db.Dialector.Name() == DriverPostgres {
+		operator = "ILIKE"
+	}
+	return column + " " + operator + " ? ESCAPE '!'"
+}
+
+// EscapeLike escapes the LIKE wildcards in a search term.
+func EscapeLike(term string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(term)
+}
+
+// OrderBy returns an ORDER BY item sorting NULLs the MySQL way on every database:
+// first in ascending and last in descending order.
+// PostgreSQL does the opposite by default, SQLite behaves like MySQL.
+func OrderBy(db *gorm.DB, column string, desc bool) string {
+	direction := "asc"
+	if desc {
+		direction = "desc"
+	}
+	if db.Dialector.Name() == DriverPostgres {
+		if desc {
+			return column + " desc nulls last"
+		}
+		return column + " asc nulls first"
+	}
+	return column + " " + direction
+}
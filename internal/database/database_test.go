@@ -0,0 +1,83 @@
+package database
+
+import (
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type likeRow struct {
+	ID    uint
+	Title string
+}
+
+// newSQLite returns an empty in-memory SQLite database.
+func newSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := DB_Connect(DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	for dsn, want := range map[string]string{
+		"":                   "clinic.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)",
+		"data/clinic.db":     "data/clinic.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)",
+		"clinic.db?mode=rwc": "clinic.db?mode=rwc&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)",
+	} {
+		if got := sqliteDSN(dsn); got != want {
+			t.Errorf("sqliteDSN(%q) = %q, want %q", dsn, got, want)
+		}
+	}
+}
+
+func TestConnectUnsupportedDriver(t *testing.T) {
+	if _, err := DB_Connect("oracle", ""); err == nil {
+		t.Fatal("DB_Connect accepted an unsupported driver")
+	}
+}
+
+func TestSQLiteForeignKeys(t *testing.T) {
+	db := newSQLite(t)
+	var enabled int
+	if err := db.Raw("PRAGMA foreign_keys").Scan(&enabled).Error; err != nil {
+		t.Fatal(err)
+	}
+	if enabled != 1 {
+		t.Fatalf("foreign_keys = %d, want 1", enabled)
+	}
+}
+
+func TestLike(t *testing.T) {
+	db := newSQLite(t)
+	if err := db.AutoMigrate(&likeRow{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"100% natural", "1000 natural", "snake_case", "snakescase", "Wow!"} {
+		if err := db.Create(&likeRow{Title: title}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for term, want := range map[string][]string{
+		"0%":      {"100% natural"},
+		"e_c":     {"snake_case"},
+		"w!":      {"Wow!"},
+		"NATURAL": {"100% natural", "1000 natural"},
+	} {
+		var got []string
+		if err := db.Model(&likeRow{}).Where(Like(db, "title"), "%"+EscapeLike(term)+"%").Order("id").Pluck("title", &got).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("Like %q found %v, want %v", term, got, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package database
+
+import "gorm.io/gorm"
+
+// SearchIndex describes the FULLTEXT index of a searchable table.
+// MATCH() has to list exactly the columns of the index.
+type SearchIndex struct {
+	Table   string
+	Name    string
+	Columns []string
+}
+
+// SearchIndexes are the searchable tables, keyed by the hit type of the search API.
+// The indexes are created by migrations, changing the columns needs a new one.
+var SearchIndexes = map[string]SearchIndex{
+	"program": {
+		Table: "programs",
+		Name:  "idx_programs_search",
+		Columns: []string{
+			"title", "title_pl", "title_en", "title_uk",
+			"description", "description_pl", "description_en", "description_uk",
+			"results", "results_pl", "results_en", "results_uk",
+		},
+	},
+	"price": {
+		Table:   "prices",
+		Name:    "idx_prices_search",
+		Columns: []string{"item_name", "item_name_pl", "item_name_en", "item_name_uk"},
+	},
+	"news": {
+		Table: "news",
+		Name:  "idx_news_search",
+		Columns: []string{
+			"title", "title_pl", "title_en", "title_uk",
+			"description", "description_pl", "description_en", "description_uk",
+			"features", "features_pl", "features_en", "features_uk",
+		},
+	},
+}
+
+// SupportsFullText reports whether the database has MySQL FULLTEXT search.
+// Other databases are searched with LIKE.
+func SupportsFullText(db *gorm.DB) bool {
+	return db.Dialector.Name() == "mysql"
+}
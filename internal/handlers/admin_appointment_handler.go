@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// appointmentSlotLayout is the format used by the datetime-local input of the reschedule form.
+const appointmentSlotLayout = "2006-01-02T15:04"
+
+// Rendering appointments page
+func ShowAppointmentsPage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var appointments []models.Appointment
+		query := db.Preload("Program").Order("requested_at asc")
+		// Optional status filter, e.g. ?status=pending
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+		query.Find(&appointments)
+		session := sessions.Default(c)
+		userName := session.Get("userName")
+		userRole := session.Get("userRole")
+
+		c.HTML(http.StatusOK, "appointments.html", gin.H{
+			"Title":     "Manage Appointments",
+			"CSRFToken": csrfToken(c),
+			"User":      userName,
+			"UserRole":  userRole,
+			"Items":     appointments,
+			"Statuses":  models.AllAppointmentStatuses,
+			"Status":    c.Query("status"),
+		})
+	}
+}
+
+// AdminShowEditAppointmentForm finds an appointment by ID and renders the reschedule form.
+func AdminShowEditAppointmentForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var appointment models.Appointment
+		if err := db.Preload("Program").First(&appointment, id).Error; err != nil {
+			// Handle the case where no record found
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.Status(http.StatusNotFound)
+			} else {
+				log.Printf("Failed to find appointment with ID %s: %s", id, err)
+				ctx.Status(http.StatusInternalServerError)
+			}
+			return
+		}
+		ctx.HTML(http.StatusOK, "appointment-form.html", gin.H{
+			"Appointment": appointment,
+		})
+	}
+}
+
+// AdminUpdateAppointment handles the submission of the reschedule form.
+func AdminUpdateAppointment(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var appointment models.Appointment
+		if err := db.Preload("Program").First(&appointment, id).Error; err != nil {
+			log.Printf("Failed to find appointment with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		// Finished appointments can not be moved anymore
+		if appointment.Status == models.AppointmentCancelled || appointment.Status == models.AppointmentCompleted {
+			ctx.Status(http.StatusConflict)
+			return
+		}
+
+		before := auditState(appointment)
+		slot, err := time.ParseInLocation(appointmentSlotLayout, ctx.PostForm("requested_at"), time.Local)
+		if err != nil {
+			log.Printf("Failed to parse appointment slot: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		if !slot.After(time.Now()) {
+			formError(ctx, "#appointment-form-error", "Requested slot must be in the future")
+			return
+		}
+		if err := checkSlot(db, appointment.Program, slot, time.Now(), appointment.ID); err != nil {
+			log.Printf("Failed to reschedule appointment with ID %s: %s", id, err)
+			if errors.Is(err, errSlotTaken) {
+				formConflict(ctx, "#appointment-form-error", "Requested slot is not available, the clinic or the specialists are not free then")
+				return
+			}
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		appointment.RequestedAt = slot
+		appointment.Notes = ctx.PostForm("notes")
+
+		// Save updates to the DB
+		if err := db.Save(&appointment).Error; err != nil {
+			log.Printf("Failed to update appointment with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditUpdate, &appointment, before)
+		session := sessions.Default(ctx)
+		userRole := session.Get("userRole")
+		ctx.HTML(http.StatusOK, "appointment-row.html", gin.H{
+			"Item":     appointment,
+			"UserRole": userRole,
+		})
+	}
+}
+
+// AdminUpdateAppointmentStatus confirms, cancels or completes an appointment.
+func AdminUpdateAppointmentStatus(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var appointment models.Appointment
+		if err := db.Preload("Program").First(&appointment, id).Error; err != nil {
+			log.Printf("Failed to find appointment with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+
+		status := ctx.PostForm("status")
+		if !appointment.CanTransition(status) {
+			log.Printf("Invalid appointment status change from %s to %s", appointment.Status, status)
+			ctx.Status(http.StatusConflict)
+			return
+		}
+		before := auditState(appointment)
+		appointment.Status = status
+
+		if err := db.Model(&appointment).Update("status", status).Error; err != nil {
+			log.Printf("Failed to update appointment with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditUpdate, &appointment, before)
+		session := sessions.Default(ctx)
+		userRole := session.Get("userRole")
+		ctx.HTML(http.StatusOK, "appointment-row.html", gin.H{
+			"Item":     appointment,
+			"UserRole": userRole,
+		})
+	}
+}
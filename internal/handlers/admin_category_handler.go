@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Rendering categories page
+func ShowCategoriesPage(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		userName := session.Get("userName")
+		userRole := session.Get("userRole")
+		flashes := session.Flashes("error")
+		if err := session.Save(); err != nil {
+			log.Printf("Failed to save session to clear flashes: %s", err)
+		}
+		renderData := gin.H{
+			"Title":     "Manage Categories",
+			"CSRFToken": csrfToken(ctx),
+			"User":      userName,
+			"UserRole":  userRole,
+			"Items":     loadCategories(db),
+		}
+		if len(flashes) > 0 {
+			renderData["error"] = flashes[0]
+		}
+		ctx.HTML(http.StatusOK, "categories.html", renderData)
+	}
+}
+
+// Render new category template
+func AdminShowNewCategoryForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.HTML(http.StatusOK, "category-form.html", withTranslations(db, &models.Category{}, gin.H{
+			"Category": models.Category{},
+		}))
+	}
+}
+
+// Create new category
+func AdminCreateCategory(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var category models.Category
+		if err := ctx.ShouldBind(&category); err != nil {
+			log.Printf("Failed to bind category data: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		category.Code = strings.ToUpper(strings.TrimSpace(category.Code))
+		if len(category.Code) != 2 {
+			log.Printf("Invalid category code %q", category.Code)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		translation.FillDefaults(&category)
+
+		// Process and save icon if provided
+		if file, err := ctx.FormFile("icon"); err == nil {
+			savedPath, err := uploadImage(ctx, db, file)
+			if err != nil {
+				log.Printf("Failed to process and save category icon: %s", err)
+				if imageRejected(ctx, err, "#category-form-error", "") {
+					return
+				}
+				ctx.Status(http.StatusInternalServerError)
+				return
+			}
+			category.Icon = savedPath
+		}
+
+		if err := createCategory(db, &category); err != nil {
+			log.Printf("Failed to create category: %s", err)
+			// The icon may have just been uploaded for nothing
+			releaseImages(db, category.Icon)
+			if errors.Is(err, errDuplicateCategory) {
+				formConflict(ctx, "#category-form-error", "Another category has this code or slug, it may be in the trash")
+				return
+			}
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if err := storeFormTranslations(ctx, db, &category); err != nil {
+			log.Printf("Failed to save category translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &category, nil)
+		session := sessions.Default(ctx)
+		userRole := session.Get("userRole")
+		ctx.HTML(http.StatusOK, "category-row.html", gin.H{
+			"Item":     category,
+			"UserRole": userRole,
+		})
+	}
+}
+
+// AdminDeleteCategory deletes a category that is not used by any program or price.
+func AdminDeleteCategory(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var category models.Category
+		if err := db.First(&category, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.Status(http.StatusNotFound)
+				return
+			}
+			log.Printf("Failed to find category with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+
+		inUse, err := categoryInUse(db, category.Code)
+		if err != nil {
+			log.Printf("Failed to check category usage: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if inUse {
+			session := sessions.Default(ctx)
+			session.AddFlash("Cannot delete a category used by programs or prices.", "error")
+			if err := session.Save(); err != nil {
+				log.Printf("Failed to save session: %s", err)
+				ctx.Status(http.StatusInternalServerError)
+				return
+			}
+			// Tell HTMX to refresh the page to show the flash message
+			ctx.Header("HX-Refresh", "true")
+			ctx.Status(http.StatusConflict)
+			return
+		}
+
+		if err := db.Delete(&category).Error; err != nil {
+			log.Printf("Failed to delete category with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditDelete, &category, auditState(category))
+		// Return an empty response
+		ctx.String(http.StatusOK, "")
+	}
+}
+
+// AdminShowEditCategoryForm finds a category by ID and renders the edit form.
+func AdminShowEditCategoryForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var category models.Category
+		if err := db.First(&category, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.Status(http.StatusNotFound)
+			} else {
+				log.Printf("Failed to find category with ID %s: %s", id, err)
+				ctx.Status(http.StatusInternalServerError)
+			}
+			return
+		}
+		ctx.HTML(http.StatusOK, "category-form.html", withTranslations(db, &category, gin.H{
+			"Category": category,
+		}))
+	}
+}
+
+// AdminUpdateCategory handles the submission of the edit category form.
+func AdminUpdateCategory(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var category models.Category
+		if err := db.First(&category, id).Error; err != nil {
+			log.Printf("Failed to find category with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		oldCode, oldIcon := category.Code, category.Icon
+		before := auditState(category)
+		// Bind form data to the existing category struct
+		if err := ctx.ShouldBind(&category); err != nil {
+			log.Printf("Failed to bind category data: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		category.Code = strings.ToUpper(strings.TrimSpace(category.Code))
+		if len(category.Code) != 2 {
+			log.Printf("Invalid category code %q", category.Code)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+
+		// Process and save icon if provided
+		if file, err := ctx.FormFile("icon"); err == nil {
+			savedPath, err := uploadImage(ctx, db, file)
+			if err != nil {
+				log.Printf("Failed to process and save category icon: %s", err)
+				if imageRejected(ctx, err, "#category-form-error", "") {
+					return
+				}
+				ctx.Status(http.StatusInternalServerError)
+				return
+			}
+			category.Icon = savedPath
+		}
+
+		// Save updates, moving programs and prices to the new code if it changed
+		if err := saveCategory(db, &category, oldCode); err != nil {
+			log.Printf("Failed to update category with ID %s: %s", id, err)
+			if category.Icon != oldIcon {
+				// The new icon was uploaded for nothing
+				releaseImages(db, category.Icon)
+			}
+			if errors.Is(err, errDuplicateCategory) {
+				formConflict(ctx, "#category-form-error", "Another category has this code or slug, it may be in the trash")
+				return
+			}
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if err := storeFormTranslations(ctx, db, &category); err != nil {
+			log.Printf("Failed to save category translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditUpdate, &category, before)
+		// A replaced icon nothing else shows is removed
+		releaseImages(db, oldIcon)
+		session := sessions.Default(ctx)
+		userRole := session.Get("userRole")
+		ctx.HTML(http.StatusOK, "category-row.html", gin.H{
+			"Item":     category,
+			"UserRole": userRole,
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
 	"github.com/DmytroPI-dev/clinic-golang/internal/models"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
-	"log"
-	"net/http"
 )
 
 // Rendering login page
@@ -16,24 +20,44 @@ func ShowLoginPage(ctx *gin.Context) {
 	flashes := session.Flashes("error")
 	// Important: Save the session to ensure flashes are cleared for the next request
 	session.Save()
-	renderData := gin.H{}
+	renderData := gin.H{"CSRFToken": csrfToken(ctx)}
 	if len(flashes) > 0 {
 		renderData["error"] = flashes[0]
 	}
 	ctx.HTML(http.StatusOK, "login.html", renderData)
 }
 
-// Handle login
-func HandleLogin(db *gorm.DB) gin.HandlerFunc {
+// Handle login. Failed logins are recorded with the address they came from, repeated ones
+// have to wait longer and longer and too many wrong passwords lock the account.
+// Users with two-factor login continue to HandleTwoFactorLogin.
+func HandleLogin(db *gorm.DB, limits LoginLimits) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Get session
 		session := sessions.Default(ctx)
 		// Obtain userName and login
 		userName := ctx.PostForm("userName")
 		password := ctx.PostForm("password")
+		ip := ctx.ClientIP()
+		now := time.Now()
+
+		wait, err := loginDelay(db, userName, ip, now)
+		if err != nil {
+			log.Printf("Failed to check failed logins: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if wait > 0 {
+			// Rejected before the password is checked, so guessing faster gains nothing
+			wait = wait.Truncate(time.Second) + time.Second
+			session.AddFlash(fmt.Sprintf("Too many failed logins, try again in %s", wait), "error")
+			session.Save()
+			ctx.Redirect(http.StatusFound, "/admin/login")
+			return
+		}
 
 		var user models.User
 		if err := db.Where("user_name = ?", userName).First(&user).Error; err != nil {
+			recordFailedLogin(db, userName, ip, nil)
 			// User not found message
 			session.AddFlash("Invalid user name or password", "error")
 			session.Save()
@@ -41,43 +65,113 @@ func HandleLogin(db *gorm.DB) gin.HandlerFunc {
 			ctx.Redirect(http.StatusFound, "/admin/login")
 			return
 		}
+		if user.IsLocked(now) {
+			// Not even a right password opens a locked account
+			recordFailedLogin(db, userName, ip, &user)
+			session.AddFlash("This account is locked after too many failed logins, try again later", "error")
+			session.Save()
+			ctx.Redirect(http.StatusFound, "/admin/login")
+			return
+		}
 		// Check password and hash
-		err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+		err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 		if err != nil {
 			// Password do not match
+			recordFailedLogin(db, userName, ip, &user)
+			countFailedLogin(db, &user, limits, now)
 			session.AddFlash("Invalid user name or password", "error")
 			session.Save()
 			ctx.Redirect(http.StatusFound, "/admin/login")
 			return
 		}
-		// Create session
-		session.Set("userID", user.ID)
-		session.Set("userName", user.UserName)
-		session.Set("userRole", user.Role)
-		session.Save()
-
-		// Redirect to admin dashboard
-		ctx.Redirect(http.StatusFound, "/admin/programs")
+		if user.IsDisabled() {
+			session.AddFlash("This account is disabled", "error")
+			session.Save()
+			ctx.Redirect(http.StatusFound, "/admin/login")
+			return
+		}
+		if user.HasTwoFactor() {
+			// The password is right, a code of the authenticator app comes next.
+			// Failed logins are only reset once the code is right as well.
+			session.Set("pendingUserID", user.ID)
+			session.Set("pendingSince", now.Unix())
+			session.Save()
+			ctx.Redirect(http.StatusFound, "/admin/login/2fa")
+			return
+		}
+		startSession(ctx, db, &user, false)
 	}
 }
 
-// Auth Required middleware to check user auth
-func AuthRequired() gin.HandlerFunc {
+// startSession logs a user in whose password, and code when required, was right.
+func startSession(ctx *gin.Context, db *gorm.DB, user *models.User, twoFactor bool) {
+	resetFailedLogins(db, user)
+	session := sessions.Default(ctx)
+	session.Delete("pendingUserID")
+	session.Delete("pendingSince")
+	// Create session
+	session.Set("userID", user.ID)
+	session.Set("userName", user.UserName)
+	session.Set("userRole", user.Role)
+	session.Set("twoFactor", twoFactor)
+	// A fresh token after logging in, so a token seen before the login is of no use
+	newCSRFToken(ctx)
+	session.Save()
+
+	// Redirect to admin dashboard
+	ctx.Redirect(http.StatusFound, "/admin/programs")
+}
+
+// AuthRequired checks that the session belongs to a user who may still log in.
+// The user is loaded on every request, so disabling or deleting a user ends the
+// session at once, and a changed role applies without logging in again.
+func AuthRequired(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		session := sessions.Default(ctx)
-		userID := session.Get("userID")
+		userID, ok := session.Get("userID").(uint)
 		// If user is not in the session, not logging user.
-		if userID == nil {
-			// Aborting request and redirecting to login page
-			ctx.Abort()
-			ctx.Redirect(http.StatusFound, "/admin/login")
+		if !ok {
+			redirectToLogin(ctx)
 			return
 		}
+		var user models.User
+		if err := db.First(&user, userID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to find User with ID %d: %s", userID, err)
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		} else if err != nil || user.IsDisabled() {
+			session.Clear()
+			session.Save()
+			redirectToLogin(ctx)
+			return
+		}
+		// The session keeps what the user had at the login, the database has the current values
+		twoFactor, _ := session.Get("twoFactor").(bool)
+		twoFactor = twoFactor && user.HasTwoFactor()
+		if session.Get("userRole") != user.Role || session.Get("userName") != user.UserName || session.Get("twoFactor") != twoFactor {
+			session.Set("userRole", user.Role)
+			session.Set("userName", user.UserName)
+			session.Set("twoFactor", twoFactor)
+			session.Save()
+		}
 		ctx.Next()
 	}
 }
 
-// HandleLogout clears the user's session and redirects to the login page.
+// redirectToLogin aborts a request without a valid session and sends the browser to
+// the login page. HTMX requests are redirected as a whole page, not swapped in.
+func redirectToLogin(ctx *gin.Context) {
+	if ctx.GetHeader("HX-Request") == "true" {
+		ctx.Header("HX-Redirect", "/admin/login")
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	ctx.Abort()
+	ctx.Redirect(http.StatusFound, "/admin/login")
+}
+
+// HandleLogout clears the user's session and redirects to the login page. It only answers
+// POST, so the CSRF token keeps other sites from logging users out.
 func HandleLogout(ctx *gin.Context) {
 	session := sessions.Default(ctx)
 	session.Clear()
@@ -102,7 +196,7 @@ func RoleRequired(allowedRoles ...string) gin.HandlerFunc {
 		if !ok {
 			// The userRole in session is not a string, which is unexpected.
 			log.Printf("userRole in session is not a string: %v", userRoleVal)
-			ctx.HTML(http.StatusForbidden, "403.html", gin.H{"Title": "Forbidden"})
+			ctx.HTML(http.StatusForbidden, "403.html", gin.H{"Title": "Forbidden", "CSRFToken": csrfToken(ctx)})
 			ctx.Abort()
 			return
 		}
@@ -117,7 +211,7 @@ func RoleRequired(allowedRoles ...string) gin.HandlerFunc {
 		}
 		if !isAllowed {
 			// User's role is not permitted. Show a "Forbidden" error.
-			ctx.HTML(http.StatusForbidden, "403.html", gin.H{"Title": "Forbidden"})
+			ctx.HTML(http.StatusForbidden, "403.html", gin.H{"Title": "Forbidden", "CSRFToken": csrfToken(ctx)})
 			ctx.Abort()
 			return
 		}
@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/database"
+	"github.com/DmytroPI-dev/clinic-golang/internal/media"
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"github.com/DmytroPI-dev/clinic-golang/internal/utils"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Number of assets on a page of the media library and in the picker of the news form.
+const (
+	mediaPageSize    = 48
+	mediaPickerLimit = 24
+)
+
+// mediaSearchColumns are the columns matched by the search of the media library.
+var mediaSearchColumns = []string{"original_name", "alt_text", "alt_text_pl", "alt_text_en", "alt_text_uk"}
+
+// uploadImage saves an image uploaded with an admin form and adds it to the media library.
+// It returns the public path of the saved file, or of the same image already in the library.
+func uploadImage(ctx *gin.Context, db *gorm.DB, file *multipart.FileHeader) (string, error) {
+	var asset models.MediaAsset
+	asset.UserID, _, _ = changedBy(ctx, db)
+	if err := media.Save(db, file, &asset); errors.Is(err, media.ErrDuplicate) {
+		return asset.Path, nil
+	} else if err != nil {
+		return "", err
+	}
+	recordAudit(ctx, db, models.AuditCreate, &asset, nil)
+	return asset.Path, nil
+}
+
+// imageRejected answers an upload rejected by the validation with its reason, shown in
+// the form element target, e.g. "#news-form-error", and reports whether it was one.
+// The label names the image when a form has several, e.g. "Left image".
+func imageRejected(ctx *gin.Context, err error, target, label string) bool {
+	var rejected *utils.ImageError
+	if !errors.As(err, &rejected) {
+		return false
+	}
+	message := rejected.Reason
+	if label != "" {
+		message = label + ": " + message
+	}
+	formError(ctx, target, message)
+	return true
+}
+
+// formError shows a message in the form element target, leaving the form open.
+func formError(ctx *gin.Context, target, message string) {
+	formMessage(ctx, http.StatusUnprocessableEntity, target, message)
+}
+
+// formConflict is formError for a value another record already has.
+func formConflict(ctx *gin.Context, target, message string) {
+	formMessage(ctx, http.StatusConflict, target, message)
+}
+
+// formMessage answers with the status and the message swapped into the form element target.
+func formMessage(ctx *gin.Context, status int, target, message string) {
+	// The form keeps its modal open on an error status and swaps the message in
+	ctx.Header("HX-Retarget", target)
+	ctx.Header("HX-Reswap", "innerHTML")
+	ctx.HTML(status, "form-error.html", gin.H{"Error": message})
+}
+
+// releaseImages removes the images a record no longer shows, see media.Release.
+// A failure is only logged, as the change itself has already been saved.
+func releaseImages(db *gorm.DB, paths ...string) {
+	if err := media.Release(db, paths...); err != nil {
+		log.Printf("Failed to remove released images: %s", err)
+	}
+}
+
+// formImage returns the image of a form field: a newly uploaded file, or else the
+// media library asset picked in the <field>_asset input. It is empty when neither is given.
+func formImage(ctx *gin.Context, db *gorm.DB, field string) (string, error) {
+	if file, err := ctx.FormFile(field); err == nil {
+		return uploadImage(ctx, db, file)
+	}
+	assetID := ctx.PostForm(field + "_asset")
+	if assetID == "" {
+		return "", nil
+	}
+	var asset models.MediaAsset
+	if err := db.First(&asset, assetID).Error; err != nil {
+		return "", err
+	}
+	return asset.Path, nil
+}
+
+// mediaPicker returns the latest assets offered by the image pickers of the admin forms.
+func mediaPicker(db *gorm.DB) []models.MediaAsset {
+	var assets []models.MediaAsset
+	if err := db.Preload("Variants").Order("id desc").Limit(mediaPickerLimit).Find(&assets).Error; err != nil {
+		log.Printf("Failed to load media library: %s", err)
+	}
+	return assets
+}
+
+// ShowMediaPage renders the media library, newest first, searched by ?q= in the
+// file names and alt texts.
+func ShowMediaPage(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+		if page < 1 {
+			page = 1
+		}
+
+		// 1. Apply the search
+		search := strings.TrimSpace(ctx.Query("q"))
+		query := db.Model(&models.MediaAsset{})
+		if search != "" {
+			pattern := "%" + database.EscapeLike(search) + "%"
+			conditions := make([]string, 0, len(mediaSearchColumns))
+			args := make([]any, 0, len(mediaSearchColumns))
+			for _, column := range mediaSearchColumns {
+				conditions = append(conditions, database.Like(db, column))
+				args = append(args, pattern)
+			}
+			query = query.Where(strings.Join(conditions, " OR "), args...)
+		}
+
+		// 2. Fetch one more asset than shown to know whether there is a next page
+		var assets []models.MediaAsset
+		if err := query.Preload("User").Preload("Variants").Order("id desc").
+			Limit(mediaPageSize + 1).Offset((page - 1) * mediaPageSize).Find(&assets).Error; err != nil {
+			log.Printf("Failed to load media library: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		hasNext := len(assets) > mediaPageSize
+		if hasNext {
+			assets = assets[:mediaPageSize]
+		}
+		// Keeps the search in the page links
+		searchQuery := url.Values{}
+		if search != "" {
+			searchQuery.Set("q", search)
+		}
+		used, err := media.UsedPaths(db)
+		if err != nil {
+			log.Printf("Failed to load used images: %s", err)
+		}
+
+		ctx.HTML(http.StatusOK, "media.html", gin.H{
+			"Title":       "Media Library",
+			"CSRFToken":   csrfToken(ctx),
+			"User":        session.Get("userName"),
+			"UserRole":    session.Get("userRole"),
+			"Items":       assets,
+			"Used":        used,
+			"Search":      search,
+			"SearchQuery": searchQuery.Encode(),
+			"Page":        page,
+			"HasNext":     hasNext,
+			"PrevPage":    page - 1,
+			"NextPage":    page + 1,
+		})
+	}
+}
+
+// AdminShowMediaForm renders the upload form of the media library.
+func AdminShowMediaForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.HTML(http.StatusOK, "media-form.html", withTranslations(db, &models.MediaAsset{}, gin.H{
+			"Asset": models.MediaAsset{},
+		}))
+	}
+}
+
+// AdminCreateMedia uploads an image to the media library.
+func AdminCreateMedia(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var asset models.MediaAsset
+		if err := ctx.ShouldBind(&asset); err != nil {
+			log.Printf("Failed to bind media data: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		file, err := ctx.FormFile("image")
+		if err != nil {
+			log.Printf("No image uploaded: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		// Set empty translated fields to default language
+		translation.FillDefaults(&asset)
+		asset.UserID, _, _ = changedBy(ctx, db)
+		// Images uploaded to the library stay in it, even when no record shows them
+		asset.Pinned = true
+		if err := media.Save(db, file, &asset); errors.Is(err, media.ErrDuplicate) {
+			// It may have come with a record, it belongs to the library now
+			if err := db.Model(&asset).Update("pinned", true).Error; err != nil {
+				log.Printf("Failed to pin image %d: %s", asset.ID, err)
+			}
+			formError(ctx, "#media-form-error", "This image is already in the media library as "+asset.OriginalName)
+			return
+		} else if err != nil {
+			log.Printf("Failed to process and save image: %s", err)
+			if imageRejected(ctx, err, "#media-form-error", "") {
+				return
+			}
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if err := storeFormTranslations(ctx, db, &asset); err != nil {
+			log.Printf("Failed to save media translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &asset, nil)
+		db.Preload("User").First(&asset, asset.ID)
+		session := sessions.Default(ctx)
+		ctx.HTML(http.StatusOK, "media-row.html", gin.H{
+			"Item":     asset,
+			"UserRole": session.Get("userRole"),
+		})
+	}
+}
+
+// AdminShowEditMedia renders the alt text form of an asset.
+func AdminShowEditMedia(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		var asset models.MediaAsset
+		if err := db.First(&asset, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.Status(http.StatusNotFound)
+			} else {
+				log.Printf("Failed to find media with ID %s: %s", id, err)
+				ctx.Status(http.StatusInternalServerError)
+			}
+			return
+		}
+		ctx.HTML(http.StatusOK, "media-form.html", withTranslations(db, &asset, gin.H{
+			"Asset": asset,
+		}))
+	}
+}
+
+// mediaForm holds the fields of the media form, the rest of an asset can not be edited.
+type mediaForm struct {
+	AltText   string `form:"alt_text"`
+	AltTextPL string `form:"alt_text_pl"`
+	AltTextEN string `form:"alt_text_en"`
+	AltTextUK string `form:"alt_text_uk"`
+}
+
+// AdminUpdateMedia saves the alt texts of an asset. The image itself can not be replaced.
+func AdminUpdateMedia(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		var asset models.MediaAsset
+		if err := db.Preload("User").Preload("Variants").First(&asset, id).Error; err != nil {
+			log.Printf("Failed to find media with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		before := auditState(asset)
+		var form mediaForm
+		if err := ctx.ShouldBind(&form); err != nil {
+			log.Printf("Failed to bind media data: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		asset.AltText = form.AltText
+		asset.AltTextPL = form.AltTextPL
+		asset.AltTextEN = form.AltTextEN
+		asset.AltTextUK = form.AltTextUK
+		if err := db.Omit(clause.Associations).Save(&asset).Error; err != nil {
+			log.Printf("Failed to update media with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if err := storeFormTranslations(ctx, db, &asset); err != nil {
+			log.Printf("Failed to save media translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditUpdate, &asset, before)
+		session := sessions.Default(ctx)
+		ctx.HTML(http.StatusOK, "media-row.html", gin.H{
+			"Item":     asset,
+			"UserRole": session.Get("userRole"),
+			"Used":     media.InUse(db, asset.Path),
+		})
+	}
+}
+
+// AdminDeleteMedia deletes an asset and its file. Images still shown by news,
+// categories or specialists, deleted ones included, are kept.
+func AdminDeleteMedia(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		var asset models.MediaAsset
+		if err := db.First(&asset, id).Error; err != nil {
+			log.Printf("Failed to find media with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		before := auditState(asset)
+		if err := media.Remove(db, asset.Path); err != nil {
+			log.Printf("Failed to delete media with ID %s: %s", id, err)
+			if errors.Is(err, media.ErrInUse) {
+				ctx.Status(http.StatusConflict)
+			} else {
+				ctx.Status(http.StatusInternalServerError)
+			}
+			return
+		}
+		recordAudit(ctx, db, models.AuditDelete, &asset, before)
+		ctx.String(http.StatusOK, "")
+	}
+}
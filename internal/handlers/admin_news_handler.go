@@ -4,14 +4,33 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/DmytroPI-dev/clinic-golang/internal/models"
-	"github.com/DmytroPI-dev/clinic-golang/internal/utils"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// bindNewsSchedule reads the publication times of the news form, entered in local time.
+// Empty inputs clear the times. The status itself is bound with the other fields.
+func bindNewsSchedule(ctx *gin.Context, news *models.News) error {
+	times := make([]*time.Time, 2)
+	for i, name := range []string{"publish_at", "unpublish_at"} {
+		value := ctx.PostForm(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseInLocation(appointmentSlotLayout, value, time.Local)
+		if err != nil {
+			return err
+		}
+		times[i] = &parsed
+	}
+	return newsSchedule(news, "", times[0], times[1])
+}
+
 // Rendering news page“
 func ShowNewsPage(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -23,18 +42,24 @@ func ShowNewsPage(db *gorm.DB) gin.HandlerFunc {
 		userRole := session.Get("userRole")
 		// Render template
 		c.HTML(http.StatusOK, "news.html", gin.H{
-			"Title":    "Manage News",
-			"User":     userName,
-			"UserRole": userRole,
-			"Items":    News,
+			"Title":     "Manage News",
+			"CSRFToken": csrfToken(c),
+			"User":      userName,
+			"UserRole":  userRole,
+			"Items":     News,
 		})
 	}
 }
 
-func AdminShowNewsForm(ctx *gin.Context) {
-	ctx.HTML(http.StatusOK, "news-form.html", gin.H{
-		"News": models.News{},
-	})
+func AdminShowNewsForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// New news start as drafts, so they are not shown before they are finished
+		ctx.HTML(http.StatusOK, "news-form.html", withTranslations(db, &models.News{}, gin.H{
+			"News":     models.News{Status: models.NewsDraft},
+			"Statuses": models.AllNewsStatuses,
+			"Media":    mediaPicker(db),
+		}))
+	}
 }
 
 // Create new news template
@@ -46,51 +71,57 @@ func AdminCreateNews(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusBadRequest)
 			return
 		}
+		if newNews.Status == "" {
+			newNews.Status = models.NewsDraft
+		}
+		if err := bindNewsSchedule(ctx, &newNews); err != nil {
+			log.Printf("Failed to bind news schedule: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
 
-		// Set translated fields to default language
-		newNews.TitlePL = newNews.Title
-		newNews.HeaderPL = newNews.Header
-		newNews.DescriptionPL = newNews.Description
-		newNews.FeaturesPL = newNews.Features
-		newNews.TitleEN = newNews.Title
-		newNews.HeaderEN = newNews.Header
-		newNews.DescriptionEN = newNews.Description
-		newNews.FeaturesEN = newNews.Features
-		newNews.TitleUK = newNews.Title
-		newNews.HeaderUK = newNews.Header
-		newNews.DescriptionUK = newNews.Description
-		newNews.FeaturesUK = newNews.Features
-
-		// Process and save imageLeft if provided
-		fileLeft, errLeft := ctx.FormFile("image_left")
-		if errLeft == nil { // No error means file was provided
-			savedPathLeft, err := utils.ProcessAndSaveImages(fileLeft)
-			if err != nil {
-				log.Printf("Failed to process and save imageLeft: %s", err)
-				ctx.Status(http.StatusInternalServerError)
+		// Set empty translated fields to default language
+		translation.FillDefaults(&newNews)
+
+		// Process and save imageLeft if provided, or use the one picked from the media library
+		if pathLeft, err := formImage(ctx, db, "image_left"); err != nil {
+			log.Printf("Failed to process and save imageLeft: %s", err)
+			if imageRejected(ctx, err, "#news-form-error", "Left image") {
 				return
 			}
-			newNews.ImageLeft = savedPathLeft
+			ctx.Status(http.StatusInternalServerError)
+			return
+		} else if pathLeft != "" {
+			newNews.ImageLeft = pathLeft
 		}
 
-		// Process and save imageRight if provided
-		fileRight, errRight := ctx.FormFile("image_right")
-		if errRight == nil { // No error means file was provided
-			savedPathRight, err := utils.ProcessAndSaveImages(fileRight)
-			if err != nil {
-				log.Printf("Failed to process and save imageRight: %s", err)
-				ctx.Status(http.StatusInternalServerError)
+		// Process and save imageRight if provided, or use the one picked from the media library
+		if pathRight, err := formImage(ctx, db, "image_right"); err != nil {
+			log.Printf("Failed to process and save imageRight: %s", err)
+			// The left image may have just been uploaded for nothing
+			releaseImages(db, newNews.ImageLeft)
+			if imageRejected(ctx, err, "#news-form-error", "Right image") {
 				return
 			}
-			newNews.ImageRight = savedPathRight
+			ctx.Status(http.StatusInternalServerError)
+			return
+		} else if pathRight != "" {
+			newNews.ImageRight = pathRight
 		}
 
 		// Save the newly created news item to DB
 		if err := db.Create(&newNews).Error; err != nil {
 			log.Printf("Failed to create news: %s", err)
+			releaseImages(db, newNews.ImageLeft, newNews.ImageRight)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if err := storeFormTranslations(ctx, db, &newNews); err != nil {
+			log.Printf("Failed to save news translations: %s", err)
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		recordAudit(ctx, db, models.AuditCreate, &newNews, nil)
 		// Get user role from session to correctly render the row template
 		session := sessions.Default(ctx)
 		userRole := session.Get("userRole")
@@ -107,13 +138,21 @@ func AdminDeleteNews(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Get ID from the URL
 		id := ctx.Param("id")
+		var news models.News
+		if err := db.First(&news, id).Error; err != nil {
+			log.Printf("Failed to find news with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		before := auditState(news)
 		// Delete the News from the database
-		if err := db.Delete(&models.News{}, id).Error; err != nil {
+		if err := db.Delete(&news).Error; err != nil {
 			// We will just log the error now, later adding flash error
 			log.Printf("Failed to delete News with ID %s: %s", id, err)
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		recordAudit(ctx, db, models.AuditDelete, &news, before)
 		// Return an empty response
 		ctx.String(http.StatusOK, "")
 	}
@@ -129,17 +168,19 @@ func AdminShowEditNews(db *gorm.DB) gin.HandlerFunc {
 		if err := db.First(&news, id).Error; err != nil {
 			// Handle the case where no record found
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				ctx.HTML(http.StatusNotFound, "404.html", gin.H{"Title": "Not Found"})
+				ctx.Status(http.StatusNotFound)
 			} else {
 				log.Printf("Failed to find news with ID %s: %s", id, err)
-				ctx.Status(http.StatusNotFound)
+				ctx.Status(http.StatusInternalServerError)
 			}
 			return
 		}
 		// Render the edit form with the news data
-		ctx.HTML(http.StatusOK, "news-form.html", gin.H{
-			"News": news,
-		})
+		ctx.HTML(http.StatusOK, "news-form.html", withTranslations(db, &news, gin.H{
+			"News":     news,
+			"Statuses": models.AllNewsStatuses,
+			"Media":    mediaPicker(db),
+		}))
 	}
 }
 
@@ -157,6 +198,9 @@ func AdminUpdateNews(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusNotFound)
 			return
 		}
+		before := auditState(news)
+		revision := takeRevision(db, &news)
+		oldImages := []string{news.ImageLeft, news.ImageRight}
 
 		// Bind form data to the existing news struct
 		if err := ctx.ShouldBind(&news); err != nil {
@@ -164,29 +208,36 @@ func AdminUpdateNews(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusBadRequest)
 			return
 		}
+		if err := bindNewsSchedule(ctx, &news); err != nil {
+			log.Printf("Failed to bind news schedule: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
 
-		// Process and save imageLeft if provided
-		fileLeft, errLeft := ctx.FormFile("image_left")
-		if errLeft == nil { // No error means file was provided
-			savedPathLeft, err := utils.ProcessAndSaveImages(fileLeft)
-			if err != nil {
-				log.Printf("Failed to process and save imageLeft: %s", err)
-				ctx.Status(http.StatusInternalServerError)
+		// Process and save imageLeft if provided, or use the one picked from the media library
+		if pathLeft, err := formImage(ctx, db, "image_left"); err != nil {
+			log.Printf("Failed to process and save imageLeft: %s", err)
+			if imageRejected(ctx, err, "#news-form-error", "Left image") {
 				return
 			}
-			news.ImageLeft = savedPathLeft
+			ctx.Status(http.StatusInternalServerError)
+			return
+		} else if pathLeft != "" {
+			news.ImageLeft = pathLeft
 		}
 
-		// Process and save imageRight if provided
-		fileRight, errRight := ctx.FormFile("image_right")
-		if errRight == nil { // No error means file was provided
-			savedPathRight, err := utils.ProcessAndSaveImages(fileRight)
-			if err != nil {
-				log.Printf("Failed to process and save imageRight: %s", err)
-				ctx.Status(http.StatusInternalServerError)
+		// Process and save imageRight if provided, or use the one picked from the media library
+		if pathRight, err := formImage(ctx, db, "image_right"); err != nil {
+			log.Printf("Failed to process and save imageRight: %s", err)
+			// The left image may have just been uploaded for nothing
+			releaseImages(db, news.ImageLeft)
+			if imageRejected(ctx, err, "#news-form-error", "Right image") {
 				return
 			}
-			news.ImageRight = savedPathRight
+			ctx.Status(http.StatusInternalServerError)
+			return
+		} else if pathRight != "" {
+			news.ImageRight = pathRight
 		}
 
 		// Save updates to the DB
@@ -195,6 +246,15 @@ func AdminUpdateNews(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		if err := storeFormTranslations(ctx, db, &news); err != nil {
+			log.Printf("Failed to save news translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		saveRevision(ctx, db, revision, &news)
+		recordAudit(ctx, db, models.AuditUpdate, &news, before)
+		// Replaced images are kept while a revision shows them, see media.Release
+		releaseImages(db, oldImages...)
 		// Get user role from session to correctly render the row template
 		session := sessions.Default(ctx)
 		userRole := session.Get("userRole")
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -15,24 +16,31 @@ import (
 func ShowPricesPage(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var prices []models.Price
-		db.Order("id asc").Find(&prices)
+		db.Preload("Program").Order("id asc").Find(&prices)
 		session := sessions.Default(c)
 		userName := session.Get("userName")
 		userRole := session.Get("userRole")
 		c.HTML(http.StatusOK, "prices.html", gin.H{
-			"Title":    "Manage Prices",
-			"User":     userName,
-			"UserRole": userRole,
-			"Items":    prices,
+			"Title":     "Manage Prices",
+			"CSRFToken": csrfToken(c),
+			"User":      userName,
+			"UserRole":  userRole,
+			"Items":     prices,
 		})
 	}
 }
 
-func AdminShowNewPriceForm(ctx *gin.Context) {
-	ctx.HTML(http.StatusOK, "price-form.html", gin.H{
-		"Categories": models.AllCategories,
-		"Price":      models.Price{},
-	})
+func AdminShowNewPriceForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var programs []models.Program
+		db.Order("title asc").Find(&programs)
+		ctx.HTML(http.StatusOK, "price-form.html", withTranslations(db, &models.Price{}, gin.H{
+			"Categories": loadCategories(db),
+			"Variants":   models.AllPriceVariants,
+			"Programs":   programs,
+			"Price":      models.Price{Variant: models.PriceSingle, Sessions: 1},
+		}))
+	}
 }
 
 // Create new price template
@@ -45,23 +53,32 @@ func AdminCreateNewPrice(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// If translation fields are not submitted, populate them with the default language value.
-		if newPrice.ItemNamePL == "" {
-			newPrice.ItemNamePL = newPrice.ItemName
-		}
-		if newPrice.ItemNameEN == "" {
-			newPrice.ItemNameEN = newPrice.ItemName
+		if err := checkCategory(db, &newPrice.Category); err != nil {
+			log.Printf("Invalid price category %q: %s", newPrice.Category, err)
+			ctx.Status(http.StatusBadRequest)
+			return
 		}
-		if newPrice.ItemNameUK == "" {
-			newPrice.ItemNameUK = newPrice.ItemName
+		if err := bindPriceProgram(ctx, db, &newPrice); err != nil {
+			log.Printf("Failed to bind price program: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
 		}
 
+		// If translation fields are not submitted, populate them with the default language value.
+		translation.FillDefaults(&newPrice)
+
 		// Save the newly created price item to DB
-		if err := db.Create(&newPrice).Error; err != nil {
+		if err := db.Omit("Program").Create(&newPrice).Error; err != nil {
 			log.Printf("Failed to create price: %s", err)
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		if err := storeFormTranslations(ctx, db, &newPrice); err != nil {
+			log.Printf("Failed to save price translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &newPrice, nil)
 		// Get user role from session to correctly render the row template
 		session := sessions.Default(ctx)
 		userRole := session.Get("userRole")
@@ -78,13 +95,21 @@ func AdminDeletePrice(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Get ID from the URL
 		id := ctx.Param("id")
+		var price models.Price
+		if err := db.First(&price, id).Error; err != nil {
+			log.Printf("Failed to find price with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		before := auditState(price)
 		// Delete the price from the database
-		if err := db.Delete(&models.Price{}, id).Error; err != nil {
+		if err := db.Delete(&price).Error; err != nil {
 			// We will just log the error now, later adding flash error
 			log.Printf("Failed to delete price with ID %s: %s", id, err)
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		recordAudit(ctx, db, models.AuditDelete, &price, before)
 		// Return an empty response
 		ctx.String(http.StatusOK, "")
 	}
@@ -100,18 +125,22 @@ func AdminShowEditPriceForm(db *gorm.DB) gin.HandlerFunc {
 		if err := db.First(&price, id).Error; err != nil {
 			// Handle the case where no record found
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				ctx.HTML(http.StatusNotFound, "404.html", gin.H{"Title": "Not Found"})
+				ctx.Status(http.StatusNotFound)
 			} else {
 				log.Printf("Failed to find price with ID %s: %s", id, err)
-				ctx.Status(http.StatusNotFound)
+				ctx.Status(http.StatusInternalServerError)
 			}
 			return
 		}
+		var programs []models.Program
+		db.Order("title asc").Find(&programs)
 		// Render the edit form with the price data
-		ctx.HTML(http.StatusOK, "price-form.html", gin.H{
-			"Categories": models.AllCategories,
+		ctx.HTML(http.StatusOK, "price-form.html", withTranslations(db, &price, gin.H{
+			"Categories": loadCategories(db),
+			"Variants":   models.AllPriceVariants,
+			"Programs":   programs,
 			"Price":      price,
-		})
+		}))
 	}
 }
 
@@ -129,6 +158,8 @@ func AdminUpdatePrice(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusNotFound)
 			return
 		}
+		before := auditState(price)
+		revision := takeRevision(db, &price)
 
 		// Bind form data to the existing price struct
 		if err := ctx.ShouldBind(&price); err != nil {
@@ -136,13 +167,30 @@ func AdminUpdatePrice(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusBadRequest)
 			return
 		}
+		if err := checkCategory(db, &price.Category); err != nil {
+			log.Printf("Invalid price category %q: %s", price.Category, err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		if err := bindPriceProgram(ctx, db, &price); err != nil {
+			log.Printf("Failed to bind price program: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
 
 		// Save updates to the DB
-		if err := db.Save(&price).Error; err != nil {
+		if err := db.Omit("Program").Save(&price).Error; err != nil {
 			log.Printf("Failed to update price with ID %s: %s", id, err)
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		if err := storeFormTranslations(ctx, db, &price); err != nil {
+			log.Printf("Failed to save price translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		saveRevision(ctx, db, revision, &price)
+		recordAudit(ctx, db, models.AuditUpdate, &price, before)
 		// Get user role from session to correctly render the row template
 		session := sessions.Default(ctx)
 		userRole := session.Get("userRole")
@@ -153,3 +201,24 @@ func AdminUpdatePrice(db *gorm.DB) gin.HandlerFunc {
 		})
 	}
 }
+
+// bindPriceProgram reads the optional program and variant of a price from the submitted form.
+func bindPriceProgram(ctx *gin.Context, db *gorm.DB, price *models.Price) error {
+	price.ProgramID = nil
+	price.Program = nil
+	if value := ctx.PostForm("program_id"); value != "" {
+		var program models.Program
+		if err := db.First(&program, value).Error; err != nil {
+			return err
+		}
+		price.ProgramID = &program.ID
+		price.Program = &program
+	}
+	if price.Variant == "" {
+		price.Variant = models.PriceSingle
+	}
+	if price.Sessions < 1 {
+		price.Sessions = 1
+	}
+	return nil
+}
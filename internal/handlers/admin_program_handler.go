@@ -6,17 +6,20 @@ import (
 	"net/http"
 
 	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 // Render new program template
-func AdminShowNewProgramForm(ctx *gin.Context) {
-	ctx.HTML(http.StatusOK, "program-form.html", gin.H{
-		"Categories": models.AllCategories,
-		"Program":    models.Program{},
-	})
+func AdminShowNewProgramForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.HTML(http.StatusOK, "program-form.html", withTranslations(db, &models.Program{}, gin.H{
+			"Categories": loadCategories(db),
+			"Program":    models.Program{},
+		}))
+	}
 }
 
 // Rendering programs
@@ -30,10 +33,11 @@ func ShowProgramsPage(db *gorm.DB) gin.HandlerFunc {
 
 		// Render the specific page template. It will handle the layout.
 		c.HTML(http.StatusOK, "programs.html", gin.H{
-			"Title":    "Manage Programs",
-			"User":     userName,
-			"UserRole": userRole,
-			"Items":    programs,
+			"Title":     "Manage Programs",
+			"CSRFToken": csrfToken(c),
+			"User":      userName,
+			"UserRole":  userRole,
+			"Items":     programs,
 		})
 	}
 }
@@ -47,12 +51,26 @@ func AdminCreateNewProgram(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusBadRequest)
 			return
 		}
+		if err := checkCategory(db, &newProgram.Category); err != nil {
+			log.Printf("Invalid program category %q: %s", newProgram.Category, err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		// If translation fields are not submitted, populate them with the default language value.
+		translation.FillDefaults(&newProgram)
+
 		// Save the newly created program to DB
 		if err := db.Create(&newProgram).Error; err != nil {
 			log.Printf("Failed to create program: %s", err)
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		if err := storeFormTranslations(ctx, db, &newProgram); err != nil {
+			log.Printf("Failed to save program translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &newProgram, nil)
 		// Get user role from session to correctly render the row template
 		session := sessions.Default(ctx)
 		userRole := session.Get("userRole")
@@ -69,13 +87,21 @@ func AdminDeleteProgram(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Get ID from the URL
 		id := ctx.Param("id")
+		var program models.Program
+		if err := db.First(&program, id).Error; err != nil {
+			log.Printf("Failed to find program with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		before := auditState(program)
 		// Delete the program from the database
-		if err := db.Delete(&models.Program{}, id).Error; err != nil {
+		if err := db.Delete(&program).Error; err != nil {
 			// We will just log the error now, later adding flash error
 			log.Printf("Failed to delete program with ID %s: %s", id, err)
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		recordAudit(ctx, db, models.AuditDelete, &program, before)
 		// Return an empty response
 		ctx.String(http.StatusOK, "")
 	}
@@ -91,18 +117,18 @@ func AdminShowEditProgramForm(db *gorm.DB) gin.HandlerFunc {
 		if err := db.First(&program, id).Error; err != nil {
 			// Handle the case where no record found
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				ctx.HTML(http.StatusNotFound, "404.html", gin.H{"Title": "Not Found"})
+				ctx.Status(http.StatusNotFound)
 			} else {
 				log.Printf("Failed to find program with ID %s: %s", id, err)
-				ctx.Status(http.StatusNotFound)
+				ctx.Status(http.StatusInternalServerError)
 			}
 			return
 		}
 		// Render the edit form with the program data
-		ctx.HTML(http.StatusOK, "program-form.html", gin.H{
-			"Categories": models.AllCategories,
+		ctx.HTML(http.StatusOK, "program-form.html", withTranslations(db, &program, gin.H{
+			"Categories": loadCategories(db),
 			"Program":    program,
-		})
+		}))
 	}
 }
 
@@ -120,12 +146,19 @@ func AdminUpdateProgram(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusNotFound)
 			return
 		}
+		before := auditState(program)
+		revision := takeRevision(db, &program)
 		// Bind form data to the existing program struct
 		if err := ctx.ShouldBind(&program); err != nil {
 			log.Printf("Failed to bind program data: %s", err)
 			ctx.Status(http.StatusBadRequest)
 			return
 		}
+		if err := checkCategory(db, &program.Category); err != nil {
+			log.Printf("Invalid program category %q: %s", program.Category, err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
 
 		// Save updates to the DB
 		if err := db.Save(&program).Error; err != nil {
@@ -133,6 +166,13 @@ func AdminUpdateProgram(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		if err := storeFormTranslations(ctx, db, &program); err != nil {
+			log.Printf("Failed to save program translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		saveRevision(ctx, db, revision, &program)
+		recordAudit(ctx, db, models.AuditUpdate, &program, before)
 		// Get user role from session to correctly render the row template
 		session := sessions.Default(ctx)
 		userRole := session.Get("userRole")
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// weekdays lists the days in the order shown in the admin panel, starting on Monday.
+var weekdays = []int{1, 2, 3, 4, 5, 6, 0}
+
+// Rendering schedules page
+func ShowSchedulesPage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Opening hours for every day, days without a record are shown as closed
+		var stored []models.OpeningHours
+		db.Find(&stored)
+		byWeekday := make(map[int]models.OpeningHours, len(stored))
+		for _, h := range stored {
+			byWeekday[h.Weekday] = h
+		}
+		hours := make([]models.OpeningHours, 0, len(weekdays))
+		for _, day := range weekdays {
+			h, ok := byWeekday[day]
+			if !ok {
+				h = models.OpeningHours{Weekday: day, Closed: true}
+			}
+			hours = append(hours, h)
+		}
+
+		var shifts []models.SpecialistSchedule
+		db.Preload("Specialist").Order("specialist_id asc, weekday asc, starts_at asc").Find(&shifts)
+		var exceptions []models.ScheduleException
+		db.Preload("Specialist").Order("starts_on desc").Find(&exceptions)
+
+		session := sessions.Default(c)
+		userName := session.Get("userName")
+		userRole := session.Get("userRole")
+		c.HTML(http.StatusOK, "schedules.html", gin.H{
+			"Title":      "Manage Schedules",
+			"CSRFToken":  csrfToken(c),
+			"User":       userName,
+			"UserRole":   userRole,
+			"Hours":      hours,
+			"Shifts":     shifts,
+			"Exceptions": exceptions,
+		})
+	}
+}
+
+// AdminUpdateOpeningHours saves the opening hours of one weekday.
+func AdminUpdateOpeningHours(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		weekday, err := strconv.Atoi(ctx.Param("weekday"))
+		if err != nil || weekday < 0 || weekday > 6 {
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		var hours models.OpeningHours
+		db.Where("weekday = ?", weekday).FirstOrInit(&hours, models.OpeningHours{Weekday: weekday})
+		// Weekdays without a saved row are created
+		action, before := models.AuditCreate, map[string]any(nil)
+		if hours.ID != 0 {
+			action, before = models.AuditUpdate, auditState(hours)
+		}
+		hours.Closed = ctx.PostForm("closed") == "on"
+		hours.OpensAt = ctx.PostForm("opens_at")
+		hours.ClosesAt = ctx.PostForm("closes_at")
+		if !hours.Closed && !validWindow(hours.OpensAt, hours.ClosesAt) {
+			log.Printf("Invalid opening hours %s-%s", hours.OpensAt, hours.ClosesAt)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		if err := db.Save(&hours).Error; err != nil {
+			log.Printf("Failed to save opening hours for weekday %d: %s", weekday, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, action, &hours, before)
+		session := sessions.Default(ctx)
+		ctx.HTML(http.StatusOK, "opening-hours-row.html", gin.H{
+			"Item":     hours,
+			"UserRole": session.Get("userRole"),
+		})
+	}
+}
+
+// Render new shift form
+func AdminShowNewShiftForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var specialists []models.Specialist
+		db.Order("name asc").Find(&specialists)
+		ctx.HTML(http.StatusOK, "shift-form.html", gin.H{
+			"Specialists": specialists,
+			"Weekdays":    weekdayOptions(),
+		})
+	}
+}
+
+// AdminCreateShift adds a weekly working shift for a specialist.
+func AdminCreateShift(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		specialistID, err := strconv.ParseUint(ctx.PostForm("specialist_id"), 10, 64)
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		weekday, err := strconv.Atoi(ctx.PostForm("weekday"))
+		if err != nil || weekday < 0 || weekday > 6 {
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		shift := models.SpecialistSchedule{
+			SpecialistID: uint(specialistID),
+			Weekday:      weekday,
+			StartsAt:     ctx.PostForm("starts_at"),
+			EndsAt:       ctx.PostForm("ends_at"),
+		}
+		if !validWindow(shift.StartsAt, shift.EndsAt) {
+			log.Printf("Invalid shift %s-%s", shift.StartsAt, shift.EndsAt)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		if err := db.First(&shift.Specialist, shift.SpecialistID).Error; err != nil {
+			log.Printf("Failed to find specialist with ID %d: %s", shift.SpecialistID, err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		if err := db.Create(&shift).Error; err != nil {
+			log.Printf("Failed to create shift: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &shift, nil)
+		session := sessions.Default(ctx)
+		ctx.HTML(http.StatusOK, "shift-row.html", gin.H{
+			"Item":     shift,
+			"UserRole": session.Get("userRole"),
+		})
+	}
+}
+
+// AdminDeleteShift removes a working shift.
+func AdminDeleteShift(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		var shift models.SpecialistSchedule
+		if err := db.First(&shift, id).Error; err != nil {
+			log.Printf("Failed to find shift with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		before := auditState(shift)
+		if err := db.Delete(&shift).Error; err != nil {
+			log.Printf("Failed to delete shift with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditDelete, &shift, before)
+		ctx.String(http.StatusOK, "")
+	}
+}
+
+// Render new exception form
+func AdminShowNewExceptionForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var specialists []models.Specialist
+		db.Order("name asc").Find(&specialists)
+		ctx.HTML(http.StatusOK, "exception-form.html", gin.H{
+			"Specialists": specialists,
+		})
+	}
+}
+
+// AdminCreateException adds a holiday for the clinic or a vacation for a specialist.
+func AdminCreateException(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		startsOn, err := time.Parse(dateLayout, ctx.PostForm("starts_on"))
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		endsOn := startsOn
+		if value := ctx.PostForm("ends_on"); value != "" {
+			if endsOn, err = time.Parse(dateLayout, value); err != nil || endsOn.Before(startsOn) {
+				ctx.Status(http.StatusBadRequest)
+				return
+			}
+		}
+		exception := models.ScheduleException{
+			StartsOn: startsOn,
+			EndsOn:   endsOn,
+			Reason:   ctx.PostForm("reason"),
+		}
+		// Empty specialist means the whole clinic is closed
+		if value := ctx.PostForm("specialist_id"); value != "" {
+			specialistID, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				ctx.Status(http.StatusBadRequest)
+				return
+			}
+			var specialist models.Specialist
+			if err := db.First(&specialist, specialistID).Error; err != nil {
+				log.Printf("Failed to find specialist with ID %d: %s", specialistID, err)
+				ctx.Status(http.StatusBadRequest)
+				return
+			}
+			exception.SpecialistID = &specialist.ID
+			exception.Specialist = &specialist
+		}
+		if err := db.Create(&exception).Error; err != nil {
+			log.Printf("Failed to create schedule exception: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &exception, nil)
+		session := sessions.Default(ctx)
+		ctx.HTML(http.StatusOK, "exception-row.html", gin.H{
+			"Item":     exception,
+			"UserRole": session.Get("userRole"),
+		})
+	}
+}
+
+// AdminDeleteException removes a holiday or vacation.
+func AdminDeleteException(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		var exception models.ScheduleException
+		if err := db.First(&exception, id).Error; err != nil {
+			log.Printf("Failed to find schedule exception with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		before := auditState(exception)
+		if err := db.Delete(&exception).Error; err != nil {
+			log.Printf("Failed to delete schedule exception with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditDelete, &exception, before)
+		ctx.String(http.StatusOK, "")
+	}
+}
+
+// validWindow checks that both values are "HH:MM" times and the window is not empty.
+func validWindow(start, end string) bool {
+	startOffset, err := parseClock(start)
+	if err != nil {
+		return false
+	}
+	endOffset, err := parseClock(end)
+	if err != nil {
+		return false
+	}
+	return endOffset > startOffset
+}
+
+// weekdayOptions returns the weekdays for select inputs.
+func weekdayOptions() []time.Weekday {
+	options := make([]time.Weekday, 0, len(weekdays))
+	for _, day := range weekdays {
+		options = append(options, time.Weekday(day))
+	}
+	return options
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Rendering specialists page
+func ShowSpecialistsPage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var specialists []models.Specialist
+		db.Preload("Programs").Order("id asc").Find(&specialists)
+		session := sessions.Default(c)
+		userName := session.Get("userName")
+		userRole := session.Get("userRole")
+		c.HTML(http.StatusOK, "specialists.html", gin.H{
+			"Title":     "Manage Specialists",
+			"CSRFToken": csrfToken(c),
+			"User":      userName,
+			"UserRole":  userRole,
+			"Items":     specialists,
+		})
+	}
+}
+
+// Render new specialist template
+func AdminShowNewSpecialistForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var programs []models.Program
+		db.Order("title asc").Find(&programs)
+		ctx.HTML(http.StatusOK, "specialist-form.html", withTranslations(db, &models.Specialist{}, gin.H{
+			"Specialist": models.Specialist{},
+			"Programs":   programs,
+			"Selected":   map[uint]bool{},
+		}))
+	}
+}
+
+// Create new specialist
+func AdminCreateSpecialist(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var specialist models.Specialist
+		if err := ctx.ShouldBind(&specialist); err != nil {
+			log.Printf("Failed to bind specialist data: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		translation.FillDefaults(&specialist)
+
+		// Process and save photo if provided
+		if file, err := ctx.FormFile("photo"); err == nil {
+			savedPath, err := uploadImage(ctx, db, file)
+			if err != nil {
+				log.Printf("Failed to process and save photo: %s", err)
+				if imageRejected(ctx, err, "#specialist-form-error", "") {
+					return
+				}
+				ctx.Status(http.StatusInternalServerError)
+				return
+			}
+			specialist.Photo = savedPath
+		}
+
+		if err := findPrograms(db, formProgramIDs(ctx), &specialist.Programs); err != nil {
+			log.Printf("Failed to find specialist programs: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+
+		// Save the newly created specialist to DB
+		if err := db.Create(&specialist).Error; err != nil {
+			log.Printf("Failed to create specialist: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if err := storeFormTranslations(ctx, db, &specialist); err != nil {
+			log.Printf("Failed to save specialist translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &specialist, nil)
+		// Get user role from session to correctly render the row template
+		session := sessions.Default(ctx)
+		userRole := session.Get("userRole")
+		ctx.HTML(http.StatusOK, "specialist-row.html", gin.H{
+			"Item":     specialist,
+			"UserRole": userRole,
+		})
+	}
+}
+
+// AdminDeleteSpecialist handles the deletion of a specialist from the admin panel.
+func AdminDeleteSpecialist(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var specialist models.Specialist
+		if err := db.Preload("Programs").First(&specialist, id).Error; err != nil {
+			log.Printf("Failed to find specialist with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		before := auditState(specialist)
+		if err := db.Delete(&specialist).Error; err != nil {
+			log.Printf("Failed to delete specialist with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditDelete, &specialist, before)
+		// Return an empty response
+		ctx.String(http.StatusOK, "")
+	}
+}
+
+// AdminShowEditSpecialistForm finds a specialist by ID and renders the edit form.
+func AdminShowEditSpecialistForm(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var specialist models.Specialist
+		if err := db.Preload("Programs").First(&specialist, id).Error; err != nil {
+			// Handle the case where no record found
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.Status(http.StatusNotFound)
+			} else {
+				log.Printf("Failed to find specialist with ID %s: %s", id, err)
+				ctx.Status(http.StatusInternalServerError)
+			}
+			return
+		}
+		var programs []models.Program
+		db.Order("title asc").Find(&programs)
+		// Mark programs already linked to the specialist
+		selected := make(map[uint]bool, len(specialist.Programs))
+		for _, program := range specialist.Programs {
+			selected[program.ID] = true
+		}
+		ctx.HTML(http.StatusOK, "specialist-form.html", withTranslations(db, &specialist, gin.H{
+			"Specialist": specialist,
+			"Programs":   programs,
+			"Selected":   selected,
+		}))
+	}
+}
+
+// AdminUpdateSpecialist handles the submission of the edit specialist form.
+func AdminUpdateSpecialist(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var specialist models.Specialist
+		if err := db.Preload("Programs").First(&specialist, id).Error; err != nil {
+			log.Printf("Failed to find specialist with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		before := auditState(specialist)
+		oldPhoto := specialist.Photo
+		// Bind form data to the existing specialist struct
+		if err := ctx.ShouldBind(&specialist); err != nil {
+			log.Printf("Failed to bind specialist data: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+
+		// Process and save photo if provided
+		if file, err := ctx.FormFile("photo"); err == nil {
+			savedPath, err := uploadImage(ctx, db, file)
+			if err != nil {
+				log.Printf("Failed to process and save photo: %s", err)
+				if imageRejected(ctx, err, "#specialist-form-error", "") {
+					return
+				}
+				ctx.Status(http.StatusInternalServerError)
+				return
+			}
+			specialist.Photo = savedPath
+		}
+
+		var programs []models.Program
+		if err := findPrograms(db, formProgramIDs(ctx), &programs); err != nil {
+			log.Printf("Failed to find specialist programs: %s", err)
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+
+		// Save updates and program links to the DB
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit("Programs").Save(&specialist).Error; err != nil {
+				return err
+			}
+			return tx.Model(&specialist).Association("Programs").Replace(programs)
+		})
+		if err != nil {
+			log.Printf("Failed to update specialist with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if err := storeFormTranslations(ctx, db, &specialist); err != nil {
+			log.Printf("Failed to save specialist translations: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		specialist.Programs = programs
+		recordAudit(ctx, db, models.AuditUpdate, &specialist, before)
+		// A replaced photo nothing else shows is removed
+		releaseImages(db, oldPhoto)
+		session := sessions.Default(ctx)
+		userRole := session.Get("userRole")
+		ctx.HTML(http.StatusOK, "specialist-row.html", gin.H{
+			"Item":     specialist,
+			"UserRole": userRole,
+		})
+	}
+}
+
+// formProgramIDs reads the checked program IDs from the submitted form.
+func formProgramIDs(ctx *gin.Context) []uint {
+	var ids []uint
+	for _, value := range ctx.PostFormArray("programs") {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/utils"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errPastExpiry is returned for an expiry date before today.
+var errPastExpiry = errors.New("expiry date is in the past")
+
+// Render API tokens page
+func ShowTokensPage(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var tokens []models.APIToken
+		if err := db.Preload("User").Order("id asc").Find(&tokens).Error; err != nil {
+			log.Printf("Failed to fetch API tokens: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		// Get session data
+		session := sessions.Default(ctx)
+		userName := session.Get("userName")
+		userRole := session.Get("userRole")
+		// Render template
+		ctx.HTML(http.StatusOK, "tokens.html", gin.H{
+			"Title":     "Manage API Tokens",
+			"CSRFToken": csrfToken(ctx),
+			"User":      userName,
+			"UserRole":  userRole,
+			"Items":     tokens,
+		})
+	}
+}
+
+// Render new token form
+func AdminShowNewTokenForm(ctx *gin.Context) {
+	ctx.HTML(http.StatusOK, "token-form.html", gin.H{
+		"Scopes": models.AllTokenScopes(),
+	})
+}
+
+// AdminCreateToken issues a new token for the logged in user.
+// The plain token is rendered only in the returned row and never stored.
+func AdminCreateToken(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		userID, ok := session.Get("userID").(uint)
+		if !ok {
+			ctx.Status(http.StatusUnauthorized)
+			return
+		}
+
+		name := strings.TrimSpace(ctx.PostForm("name"))
+		if name == "" {
+			formError(ctx, "#token-form-error", "Name is required")
+			return
+		}
+
+		// Keep only known scopes
+		var scopes []string
+		for _, scope := range ctx.PostFormArray("scopes") {
+			for _, known := range models.AllTokenScopes() {
+				if scope == known {
+					scopes = append(scopes, scope)
+					break
+				}
+			}
+		}
+		if len(scopes) == 0 {
+			formError(ctx, "#token-form-error", "Select at least one scope")
+			return
+		}
+
+		// Optional expiry date
+		expiresAt, err := tokenExpiry(ctx.PostForm("expires_at"), time.Now())
+		if err != nil {
+			message := "Invalid expiry date"
+			if errors.Is(err, errPastExpiry) {
+				message = "Expiry date must not be in the past"
+			}
+			formError(ctx, "#token-form-error", message)
+			return
+		}
+
+		plain, hash, err := utils.GenerateToken()
+		if err != nil {
+			log.Printf("Failed to generate API token: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+
+		token := models.APIToken{
+			Name:      name,
+			TokenHash: hash,
+			Prefix:    plain[:len(utils.TokenPrefix)+6],
+			Scopes:    strings.Join(scopes, ","),
+			UserID:    userID,
+			ExpiresAt: expiresAt,
+		}
+		if err := db.Create(&token).Error; err != nil {
+			log.Printf("Failed to create API token: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &token, nil)
+		// Load owner for the row template
+		db.First(&token.User, userID)
+
+		ctx.HTML(http.StatusOK, "token-row.html", gin.H{
+			"Item":     token,
+			"Plain":    plain,
+			"UserRole": session.Get("userRole"),
+		})
+	}
+}
+
+// AdminRevokeToken marks a token as revoked, so it can no longer be used.
+func AdminRevokeToken(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var token models.APIToken
+		if err := db.Preload("User").First(&token, id).Error; err != nil {
+			log.Printf("Failed to find API token with ID %s: %s", id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		if token.RevokedAt == nil {
+			before := auditState(token)
+			now := time.Now()
+			token.RevokedAt = &now
+			if err := db.Model(&token).Update("revoked_at", now).Error; err != nil {
+				log.Printf("Failed to revoke API token with ID %s: %s", id, err)
+				ctx.Status(http.StatusInternalServerError)
+				return
+			}
+			recordAudit(ctx, db, models.AuditUpdate, &token, before)
+		}
+		session := sessions.Default(ctx)
+		// Return the updated row
+		ctx.HTML(http.StatusOK, "token-row.html", gin.H{
+			"Item":     token,
+			"UserRole": session.Get("userRole"),
+		})
+	}
+}
+
+// tokenExpiry returns the expiry time of a token for the date picked in the form,
+// nil when none was. The token works until the end of that day in local time, so
+// today is accepted and past days are not.
+func tokenExpiry(date string, now time.Time) (*time.Time, error) {
+	if date == "" {
+		return nil, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	// The last second of the day, so the stored time keeps the date
+	endOfDay := day.AddDate(0, 0, 1).Add(-time.Second)
+	if !endOfDay.After(now) {
+		return nil, errPastExpiry
+	}
+	return &endOfDay, nil
+}
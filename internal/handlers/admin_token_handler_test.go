@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenExpiry(t *testing.T) {
+	now := time.Date(2030, 1, 7, 15, 30, 0, 0, time.Local)
+
+	expiresAt, err := tokenExpiry("2030-01-07", now)
+	if err != nil {
+		t.Fatalf("tokenExpiry of today = %v", err)
+	}
+	if want := time.Date(2030, 1, 7, 23, 59, 59, 0, time.Local); !expiresAt.Equal(want) {
+		t.Errorf("today expires at %s, want %s", expiresAt, want)
+	}
+	if _, err := tokenExpiry("2030-01-06", now); !errors.Is(err, errPastExpiry) {
+		t.Errorf("tokenExpiry of yesterday = %v, want %v", err, errPastExpiry)
+	}
+	if expiresAt, err := tokenExpiry("", now); expiresAt != nil || err != nil {
+		t.Errorf("tokenExpiry without a date = %v, %v, want no expiry", expiresAt, err)
+	}
+	if _, err := tokenExpiry("07.01.2030", now); err == nil {
+		t.Error("tokenExpiry accepted a date in another format")
+	}
+}
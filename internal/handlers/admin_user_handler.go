@@ -4,6 +4,7 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/DmytroPI-dev/clinic-golang/internal/models"
 	"github.com/gin-contrib/sessions"
@@ -17,7 +18,7 @@ func ShowUserPage(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var users []models.User
 		// Fetch all users data, excluding password hash field
-		if err := db.Select("id", "user_name", "email", "role").Order("id asc").Find(&users).Error; err != nil {
+		if err := db.Select("id", "user_name", "email", "role", "failed_logins", "locked_until", "totp_enabled_at").Order("id asc").Find(&users).Error; err != nil {
 			log.Printf("Failed to fetch users: %s", err)
 			ctx.Status(http.StatusInternalServerError)
 			return
@@ -32,10 +33,12 @@ func ShowUserPage(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		renderData := gin.H{
-			"Title":    "Manage Users",
-			"User":     userName,
-			"UserRole": userRole,
-			"Items":    users,
+			"Title":     "Manage Users",
+			"CSRFToken": csrfToken(ctx),
+			"User":      userName,
+			"UserRole":  userRole,
+			"Items":     users,
+			"Now":       time.Now(),
 		}
 		if len(flashes) > 0 {
 			renderData["error"] = flashes[0]
@@ -92,8 +95,14 @@ func AdminCreateUser(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		recordAudit(ctx, db, models.AuditCreate, &newUser, nil)
 		// Render and return HTML fragment for new row
-		ctx.HTML(http.StatusOK, "user-row.html", newUser)
+		session := sessions.Default(ctx)
+		ctx.HTML(http.StatusOK, "user-row.html", gin.H{
+			"Item":     newUser,
+			"UserRole": session.Get("userRole"),
+			"Now":      time.Now(),
+		})
 	}
 }
 
@@ -139,16 +148,29 @@ func AdminDeleteUser(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Delete User from the database completely.
-		if err := db.Unscoped().Delete(&models.User{}, id).Error; err != nil {
+		before := auditState(user)
+		if err := deleteUser(db, &user); err != nil {
 			log.Printf("Failed to delete User with ID %s: %s", id, err)
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		recordAudit(ctx, db, models.AuditDelete, &user, before)
 		// Return an empty response
 		ctx.String(http.StatusOK, "")
 	}
 }
 
+// deleteUser deletes a user together with the API tokens the user issued, revoked
+// ones included. The tokens reference the user without a delete rule.
+func deleteUser(db *gorm.DB, user *models.User) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.APIToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(user).Error
+	})
+}
+
 // AdminShowEditUserForm finds a User by ID and renders the edit form.
 func AdminShowEditUserForm(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -159,10 +181,10 @@ func AdminShowEditUserForm(db *gorm.DB) gin.HandlerFunc {
 		if err := db.First(&user, id).Error; err != nil {
 			// Handle the case where no record found
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				ctx.HTML(http.StatusNotFound, "404.html", gin.H{"Title": "Not Found"})
+				ctx.Status(http.StatusNotFound)
 			} else {
 				log.Printf("Failed to find User with ID %s: %s", id, err)
-				ctx.Status(http.StatusNotFound)
+				ctx.Status(http.StatusInternalServerError)
 			}
 			return
 		}
@@ -187,6 +209,7 @@ func AdminUpdateUser(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusNotFound)
 			return
 		}
+		before := auditState(user)
 		// Parse form data from the request
 		user.UserName = ctx.PostForm("userName")
 		user.Email = ctx.PostForm("email")
@@ -211,7 +234,44 @@ func AdminUpdateUser(db *gorm.DB) gin.HandlerFunc {
 			ctx.Status(http.StatusInternalServerError)
 			return
 		}
+		recordAudit(ctx, db, models.AuditUpdate, &user, before)
 		// Return the updated user
-		ctx.HTML(http.StatusOK, "user-row.html", user)
+		session := sessions.Default(ctx)
+		ctx.HTML(http.StatusOK, "user-row.html", gin.H{
+			"Item":     user,
+			"UserRole": session.Get("userRole"),
+			"Now":      time.Now(),
+		})
+	}
+}
+
+// AdminUnlockUser lets a user locked out after too many failed logins log in again.
+func AdminUnlockUser(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get ID from the URL
+		id := ctx.Param("id")
+		var user models.User
+		if err := db.First(&user, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.Status(http.StatusNotFound)
+				return
+			}
+			log.Printf("Failed to find User with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		before := auditState(user)
+		if err := unlockUser(db, &user, time.Now()); err != nil {
+			log.Printf("Failed to unlock User with ID %s: %s", id, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditUpdate, &user, before)
+		session := sessions.Default(ctx)
+		ctx.HTML(http.StatusOK, "user-row.html", gin.H{
+			"Item":     user,
+			"UserRole": session.Get("userRole"),
+			"Now":      time.Now(),
+		})
 	}
 }
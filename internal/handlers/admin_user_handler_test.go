@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+)
+
+func TestDeleteUserWithTokens(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "alice")
+	other := createUser(t, db, "bob")
+	revoked := time.Now()
+	for _, token := range []models.APIToken{
+		{Name: "importer", TokenHash: "hash-1", UserID: user.ID},
+		{Name: "old", TokenHash: "hash-2", UserID: user.ID, RevokedAt: &revoked},
+		{Name: "other", TokenHash: "hash-3", UserID: other.ID},
+	} {
+		if err := db.Create(&token).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := deleteUser(db, &user); err != nil {
+		t.Fatalf("deleteUser = %v", err)
+	}
+	var users int64
+	if err := db.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Count(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if users != 0 {
+		t.Fatal("the user was not deleted")
+	}
+	var left []string
+	if err := db.Unscoped().Model(&models.APIToken{}).Pluck("name", &left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0] != "other" {
+		t.Fatalf("tokens left after deleting the user: %v, want only the one of bob", left)
+	}
+}
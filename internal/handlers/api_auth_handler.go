@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APITokenRequired is a middleware that checks the "Authorization: Bearer <token>" header
+// and makes sure the token grants the given scope on the resource.
+func APITokenRequired(db *gorm.DB, resource, access string) gin.HandlerFunc {
+	scope := models.TokenScope(resource, access)
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		plain, found := strings.CutPrefix(header, "Bearer ")
+		if !found || plain == "" {
+			ctx.Header("WWW-Authenticate", `Bearer realm="api"`)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API token required"})
+			return
+		}
+
+		// Look the token up by its hash, together with the owner
+		var token models.APIToken
+		if err := db.Preload("User").Where("token_hash = ?", utils.HashToken(plain)).First(&token).Error; err != nil {
+			ctx.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API token"})
+			return
+		}
+		// Tokens of deleted or disabled users, revoked or expired tokens are rejected
+		if token.User.ID == 0 || token.User.IsDisabled() || !token.IsActive() {
+			ctx.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API token"})
+			return
+		}
+		if !token.HasScope(scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token does not grant " + scope})
+			return
+		}
+
+		// Remember when the token was used, failure here should not block the request
+		if err := db.Model(&token).UpdateColumn("last_used_at", time.Now()).Error; err != nil {
+			log.Printf("Failed to update last usage of API token %d: %s", token.ID, err)
+		}
+		ctx.Set("apiTokenID", token.ID)
+		ctx.Set("userID", token.UserID)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// phonePattern accepts international phone numbers with optional spaces, dashes and brackets.
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ()-]{6,19}$`)
+
+// AppointmentResponse defines the JSON response for a booked appointment.
+type AppointmentResponse struct {
+	ID           uint      `json:"pk"`
+	Program      uint      `json:"program"`
+	PatientName  string    `json:"patient_name"`
+	PatientPhone string    `json:"patient_phone"`
+	PatientEmail string    `json:"patient_email"`
+	RequestedAt  time.Time `json:"requested_at"`
+	Status       string    `json:"status"`
+}
+
+// CreateAppointmentRequest defines the request body for booking a program.
+type CreateAppointmentRequest struct {
+	Program      uint      `json:"program" binding:"required"`
+	PatientName  string    `json:"patient_name" binding:"required,max=150"`
+	PatientPhone string    `json:"patient_phone" binding:"required,max=30"`
+	PatientEmail string    `json:"patient_email" binding:"omitempty,email,max=255"`
+	RequestedAt  time.Time `json:"requested_at" binding:"required"`
+	Notes        string    `json:"notes" binding:"max=1000"`
+}
+
+// CreateAppointment is the public handler for booking a program.
+// New appointments are always created with pending status and have to be confirmed by an editor.
+func CreateAppointment(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request CreateAppointmentRequest
+		// 1. Bind the incoming JSON to the request struct.
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// 2. Validate the fields the binding tags cannot check.
+		request.PatientName = strings.TrimSpace(request.PatientName)
+		request.PatientPhone = strings.TrimSpace(request.PatientPhone)
+		if request.PatientName == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Patient name is required"})
+			return
+		}
+		if !phonePattern.MatchString(request.PatientPhone) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+			return
+		}
+		if !request.RequestedAt.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Requested slot must be in the future"})
+			return
+		}
+		// 3. Make sure the program exists.
+		var program models.Program
+		if err := db.First(&program, request.Program).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Program not found"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database Error"})
+			return
+		}
+		// 4. Make sure the slot is still free, see GET /api/v1/availability.
+		if err := checkSlot(db, program, request.RequestedAt, time.Now(), 0); err != nil {
+			if errors.Is(err, errSlotTaken) {
+				ctx.JSON(http.StatusConflict, gin.H{"error": "Requested slot is not available"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute availability"})
+			return
+		}
+
+		appointment := models.Appointment{
+			ProgramID:    program.ID,
+			PatientName:  request.PatientName,
+			PatientPhone: request.PatientPhone,
+			PatientEmail: request.PatientEmail,
+			RequestedAt:  request.RequestedAt,
+			Status:       models.AppointmentPending,
+			Notes:        request.Notes,
+		}
+		// 5. Create the appointment in the database.
+		if err := db.Create(&appointment).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create Appointment"})
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &appointment, nil)
+		// A 201 Created status will return
+		ctx.JSON(http.StatusCreated, toAppointmentResponse(appointment))
+	}
+}
+
+// toAppointmentResponse converts a models.Appointment to an AppointmentResponse.
+func toAppointmentResponse(appointment models.Appointment) AppointmentResponse {
+	return AppointmentResponse{
+		ID:           appointment.ID,
+		Program:      appointment.ProgramID,
+		PatientName:  appointment.PatientName,
+		PatientPhone: appointment.PatientPhone,
+		PatientEmail: appointment.PatientEmail,
+		RequestedAt:  appointment.RequestedAt,
+		Status:       appointment.Status,
+	}
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// auditPageSize is the number of entries on a page of the audit log.
+const auditPageSize = 50
+
+// auditIgnored are the fields left out of the audit diff. The ID is stored in the entry itself.
+var auditIgnored = map[string]bool{"ID": true, "CreatedAt": true, "UpdatedAt": true, "DeletedAt": true}
+
+// auditRedacted are the fields whose values are never written to the audit log.
+var auditRedacted = map[string]bool{"PasswordHash": true, "TokenHash": true, "TOTPSecret": true, "TOTPPendingSecret": true}
+
+// auditState returns the fields of a record as they are written to the audit log.
+// Take it before changing a record and pass it to recordAudit afterwards.
+// Related records are reduced to their IDs.
+func auditState(entity any) map[string]any {
+	encoded, err := json.Marshal(entity)
+	if err != nil {
+		log.Printf("Failed to encode %s for the audit log: %s", translation.EntityType(entity), err)
+		return nil
+	}
+	var state map[string]any
+	if err := json.Unmarshal(encoded, &state); err != nil {
+		return nil
+	}
+	for field, value := range state {
+		switch v := value.(type) {
+		case map[string]any:
+			// A preloaded relation, its foreign key is already in the state
+			delete(state, field)
+		case []any:
+			ids := make([]any, 0, len(v))
+			for _, item := range v {
+				if related, ok := item.(map[string]any); ok {
+					ids = append(ids, related["ID"])
+				}
+			}
+			state[field] = ids
+		}
+		if auditIgnored[field] {
+			delete(state, field)
+		}
+		if secret, ok := value.(string); ok && auditRedacted[field] && secret != "" {
+			// A fingerprint still shows that the value changed
+			sum := sha256.Sum256([]byte(secret))
+			state[field] = fmt.Sprintf("[redacted %x]", sum[:4])
+		}
+	}
+	return state
+}
+
+// auditDiff returns the fields that differ between two states.
+// Fields empty on both sides are skipped, so a create or delete lists only the set fields.
+func auditDiff(before, after map[string]any) map[string]models.AuditChange {
+	changes := make(map[string]models.AuditChange)
+	for _, state := range []map[string]any{before, after} {
+		for field := range state {
+			if _, ok := changes[field]; ok || auditEmpty(before[field]) && auditEmpty(after[field]) {
+				continue
+			}
+			if !reflect.DeepEqual(before[field], after[field]) {
+				changes[field] = models.AuditChange{Before: before[field], After: after[field]}
+			}
+		}
+	}
+	return changes
+}
+
+// auditEmpty reports whether a decoded JSON value is missing, null, an empty string or an empty list.
+func auditEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// recordAudit saves who made a change to a record. before is the auditState taken
+// before an update or delete, nil for a create. Updates without changes are skipped.
+// A failure is only logged, as the change itself has already been saved.
+func recordAudit(ctx *gin.Context, db *gorm.DB, action string, entity any, before map[string]any) {
+	var after map[string]any
+	if action != models.AuditDelete && action != models.AuditPurge {
+		after = auditState(entity)
+	}
+	changes := auditDiff(before, after)
+	if action == models.AuditUpdate && len(changes) == 0 {
+		return
+	}
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		log.Printf("Failed to encode audit changes: %s", err)
+		return
+	}
+
+	entry := models.AuditEntry{
+		Action:     action,
+		EntityType: translation.EntityType(entity),
+		EntityID:   translation.EntityID(entity),
+		Changes:    string(encoded),
+	}
+	entry.UserID, entry.UserName, entry.Source = changedBy(ctx, db)
+	if err := db.Create(&entry).Error; err != nil {
+		log.Printf("Failed to save audit entry for %s %d: %s", entry.EntityType, entry.EntityID, err)
+	}
+}
+
+// changedBy returns the user making the request and where the request came from.
+// API requests carry the token owner, admin requests the session user.
+// Requests of visitors have no user.
+func changedBy(ctx *gin.Context, db *gorm.DB) (*uint, string, string) {
+	var userID *uint
+	source := models.AuditSourcePublic
+	if id, ok := ctx.Get("userID"); ok {
+		tokenUserID := id.(uint)
+		userID, source = &tokenUserID, models.AuditSourceAPI
+	} else if id, ok := sessions.Default(ctx).Get("userID").(uint); ok {
+		userID, source = &id, models.AuditSourceAdmin
+	}
+	if userID == nil {
+		return nil, "", source
+	}
+	var user models.User
+	if err := db.Select("user_name").First(&user, *userID).Error; err != nil {
+		return userID, "", source
+	}
+	return userID, user.UserName, source
+}
+
+// ShowAuditPage renders the audit log, newest first, filtered by
+// ?user=, ?entity=, ?entity_id=, ?action=, ?from= and ?to= (YYYY-MM-DD).
+func ShowAuditPage(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+		if page < 1 {
+			page = 1
+		}
+
+		// 1. Apply the filters
+		filter := map[string]string{}
+		filterQuery := url.Values{}
+		for _, name := range []string{"user", "entity", "entity_id", "action", "from", "to"} {
+			if value := strings.TrimSpace(ctx.Query(name)); value != "" {
+				filter[name] = value
+				filterQuery.Set(name, value)
+			}
+		}
+		query := db.Model(&models.AuditEntry{})
+		if userID, ok := filter["user"]; ok {
+			query = query.Where("user_id = ?", userID)
+		}
+		if entity, ok := filter["entity"]; ok {
+			query = query.Where("entity_type = ?", entity)
+		}
+		if entityID, ok := filter["entity_id"]; ok {
+			query = query.Where("entity_id = ?", entityID)
+		}
+		if action, ok := filter["action"]; ok {
+			query = query.Where("action = ?", action)
+		}
+		if from, err := time.ParseInLocation(dateLayout, filter["from"], time.Local); err == nil {
+			query = query.Where("created_at >= ?", from)
+		}
+		if to, err := time.ParseInLocation(dateLayout, filter["to"], time.Local); err == nil {
+			// The whole "to" day is included
+			query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+		}
+
+		// 2. Fetch one more entry than shown to know whether there is a next page
+		var entries []models.AuditEntry
+		if err := query.Order("created_at desc, id desc").
+			Limit(auditPageSize + 1).Offset((page - 1) * auditPageSize).Find(&entries).Error; err != nil {
+			log.Printf("Failed to load audit log: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		hasNext := len(entries) > auditPageSize
+		if hasNext {
+			entries = entries[:auditPageSize]
+		}
+
+		// Filter options
+		var users []models.User
+		db.Select("id", "user_name").Order("user_name asc").Find(&users)
+		var entityTypes []string
+		db.Model(&models.AuditEntry{}).Distinct().Order("entity_type").Pluck("entity_type", &entityTypes)
+
+		ctx.HTML(http.StatusOK, "audit.html", gin.H{
+			"Title":       "Audit Log",
+			"CSRFToken":   csrfToken(ctx),
+			"User":        session.Get("userName"),
+			"UserRole":    session.Get("userRole"),
+			"Items":       entries,
+			"Users":       users,
+			"EntityTypes": entityTypes,
+			"Actions":     models.AllAuditActions,
+			"Filter":      filter,
+			// Keeps the filters in the page links
+			"FilterQuery": filterQuery.Encode(),
+			"Page":        page,
+			"HasNext":     hasNext,
+			"PrevPage":    page - 1,
+			"NextPage":    page + 1,
+		})
+	}
+}
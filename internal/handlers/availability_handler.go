@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// availabilitySlotStep is the distance between two possible slot starts.
+	availabilitySlotStep = 30 * time.Minute
+	// availabilityMaxDays limits the range of a single availability request.
+	availabilityMaxDays = 31
+	// defaultProgramDuration is used for programs without a configured duration.
+	defaultProgramDuration = 60
+	dateLayout             = "2006-01-02"
+)
+
+// errSlotTaken is returned for a booking that does not start a free slot of its program.
+var errSlotTaken = errors.New("requested slot is not available")
+
+// AvailabilitySlot is a single free slot returned by the availability endpoint.
+type AvailabilitySlot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Number of specialists still free for the whole slot
+	Available int `json:"available"`
+}
+
+// AvailabilityResponse is the JSON response of the availability endpoint.
+type AvailabilityResponse struct {
+	Program  uint               `json:"program"`
+	Duration int                `json:"duration"`
+	From     string             `json:"from"`
+	To       string             `json:"to"`
+	Slots    []AvailabilitySlot `json:"slots"`
+}
+
+// timeWindow is a working period on a specific day.
+type timeWindow struct {
+	start, end time.Time
+}
+
+func (w timeWindow) contains(start, end time.Time) bool {
+	return !start.Before(w.start) && !end.After(w.end)
+}
+
+// GetAvailability computes free booking slots for a program,
+// e.g. GET /api/v1/availability?program=1&from=2025-01-01&to=2025-01-07
+func GetAvailability(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// 1. Validate the query parameters
+		var program models.Program
+		if err := db.First(&program, ctx.Query("program")).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch program"})
+			}
+			return
+		}
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		from, err := parseDateQuery(ctx.Query("from"), today)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		to, err := parseDateQuery(ctx.Query("to"), from.AddDate(0, 0, 6))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		if to.Before(from) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+			return
+		}
+		if to.Sub(from) > availabilityMaxDays*24*time.Hour {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Range can not exceed %d days", availabilityMaxDays)})
+			return
+		}
+
+		slots, err := computeAvailability(db, program, from, to, now, 0)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute availability"})
+			return
+		}
+		ctx.JSON(http.StatusOK, AvailabilityResponse{
+			Program:  program.ID,
+			Duration: programDuration(program),
+			From:     from.Format(dateLayout),
+			To:       to.Format(dateLayout),
+			Slots:    slots,
+		})
+	}
+}
+
+// computeAvailability returns the free slots of a program between two days (inclusive).
+// A slot is free when more specialists performing the program work during the whole slot
+// than there are pending or confirmed appointments overlapping it. Programs without any
+// specialist are bookable by one patient at a time during the clinic opening hours.
+// ignore is an appointment left out, e.g. the one being rescheduled, 0 for none.
+func computeAvailability(db *gorm.DB, program models.Program, from, to, now time.Time, ignore uint) ([]AvailabilitySlot, error) {
+	duration := time.Duration(programDuration(program)) * time.Minute
+	end := to.AddDate(0, 0, 1)
+
+	// Clinic opening hours by weekday
+	var hours []models.OpeningHours
+	if err := db.Find(&hours).Error; err != nil {
+		return nil, err
+	}
+	openingHours := make(map[int]models.OpeningHours, len(hours))
+	for _, h := range hours {
+		openingHours[h.Weekday] = h
+	}
+
+	// Specialists performing the program
+	var specialistIDs []uint
+	if err := db.Table("specialist_programs").Where("program_id = ?", program.ID).Pluck("specialist_id", &specialistIDs).Error; err != nil {
+		return nil, err
+	}
+	var schedules []models.SpecialistSchedule
+	if len(specialistIDs) > 0 {
+		if err := db.Where("specialist_id IN ?", specialistIDs).Find(&schedules).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Exceptions overlapping the range, for the clinic or the specialists
+	var exceptions []models.ScheduleException
+	query := db.Where("starts_on < ? AND ends_on >= ?", end, from)
+	if len(specialistIDs) > 0 {
+		query = query.Where("specialist_id IS NULL OR specialist_id IN ?", specialistIDs)
+	} else {
+		query = query.Where("specialist_id IS NULL")
+	}
+	if err := query.Find(&exceptions).Error; err != nil {
+		return nil, err
+	}
+
+	// Active appointments of every program sharing a specialist with this one
+	programIDs := []uint{program.ID}
+	if len(specialistIDs) > 0 {
+		if err := db.Table("specialist_programs").Where("specialist_id IN ?", specialistIDs).Distinct().Pluck("program_id", &programIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+	var appointments []models.Appointment
+	active := db.Preload("Program").
+		Where("program_id IN ? AND status IN ?", programIDs, []string{models.AppointmentPending, models.AppointmentConfirmed}).
+		Where("requested_at >= ? AND requested_at < ?", from.Add(-24*time.Hour), end)
+	if ignore != 0 {
+		active = active.Where("id <> ?", ignore)
+	}
+	if err := active.Find(&appointments).Error; err != nil {
+		return nil, err
+	}
+
+	slots := []AvailabilitySlot{}
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		opening, ok := openingHours[int(day.Weekday())]
+		if !ok || opening.Closed {
+			continue
+		}
+		clinic, err := dayWindow(day, opening.OpensAt, opening.ClosesAt)
+		if err != nil {
+			continue
+		}
+
+		// Working windows of every specialist on that day, limited to the opening hours
+		var windows [][]timeWindow
+		clinicClosed := false
+		for _, exception := range exceptions {
+			if exception.SpecialistID == nil && exception.Covers(day) {
+				clinicClosed = true
+			}
+		}
+		if clinicClosed {
+			continue
+		}
+		if len(specialistIDs) == 0 {
+			windows = append(windows, []timeWindow{clinic})
+		}
+		for _, specialistID := range specialistIDs {
+			if specialistAbsent(exceptions, specialistID, day) {
+				continue
+			}
+			var shifts []timeWindow
+			for _, schedule := range schedules {
+				if schedule.SpecialistID != specialistID || schedule.Weekday != int(day.Weekday()) {
+					continue
+				}
+				shift, err := dayWindow(day, schedule.StartsAt, schedule.EndsAt)
+				if err != nil {
+					continue
+				}
+				shifts = append(shifts, clampWindow(shift, clinic))
+			}
+			if len(shifts) > 0 {
+				windows = append(windows, shifts)
+			}
+		}
+
+		for start := clinic.start; !start.Add(duration).After(clinic.end); start = start.Add(availabilitySlotStep) {
+			if start.Before(now) {
+				continue
+			}
+			slotEnd := start.Add(duration)
+			free := 0
+			for _, shifts := range windows {
+				for _, shift := range shifts {
+					if shift.contains(start, slotEnd) {
+						free++
+						break
+					}
+				}
+			}
+			for _, appointment := range appointments {
+				booked := appointment.RequestedAt.Add(time.Duration(programDuration(appointment.Program)) * time.Minute)
+				if appointment.RequestedAt.Before(slotEnd) && booked.After(start) {
+					free--
+				}
+			}
+			if free > 0 {
+				slots = append(slots, AvailabilitySlot{Start: start, End: slotEnd, Available: free})
+			}
+		}
+	}
+	return slots, nil
+}
+
+// checkSlot makes sure a program can be booked at the given time, that is a free slot
+// of computeAvailability starts then. It returns errSlotTaken otherwise.
+// ignore is an appointment left out, e.g. the one being rescheduled, 0 for none.
+func checkSlot(db *gorm.DB, program models.Program, start, now time.Time, ignore uint) error {
+	start = start.In(time.Local)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+	slots, err := computeAvailability(db, program, day, day, now, ignore)
+	if err != nil {
+		return err
+	}
+	for _, slot := range slots {
+		if slot.Start.Equal(start) {
+			return nil
+		}
+	}
+	return errSlotTaken
+}
+
+// programDuration returns the session length of a program in minutes.
+func programDuration(program models.Program) int {
+	if program.Duration <= 0 {
+		return defaultProgramDuration
+	}
+	return program.Duration
+}
+
+// specialistAbsent reports whether a specialist has an exception on the given day.
+func specialistAbsent(exceptions []models.ScheduleException, specialistID uint, day time.Time) bool {
+	for _, exception := range exceptions {
+		if exception.SpecialistID != nil && *exception.SpecialistID == specialistID && exception.Covers(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// dayWindow builds a time window on the given day from "HH:MM" strings.
+func dayWindow(day time.Time, start, end string) (timeWindow, error) {
+	startOffset, err := parseClock(start)
+	if err != nil {
+		return timeWindow{}, err
+	}
+	endOffset, err := parseClock(end)
+	if err != nil {
+		return timeWindow{}, err
+	}
+	if endOffset <= startOffset {
+		return timeWindow{}, errors.New("window ends before it starts")
+	}
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	return timeWindow{start: midnight.Add(startOffset), end: midnight.Add(endOffset)}, nil
+}
+
+// clampWindow limits a window to the bounds of another one.
+func clampWindow(w, bounds timeWindow) timeWindow {
+	if w.start.Before(bounds.start) {
+		w.start = bounds.start
+	}
+	if w.end.After(bounds.end) {
+		w.end = bounds.end
+	}
+	return w
+}
+
+// parseClock converts a "HH:MM" string to the offset from midnight.
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// parseDateQuery parses a "YYYY-MM-DD" query value in local time, using fallback when empty.
+func parseDateQuery(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.ParseInLocation(dateLayout, value, time.Local)
+}
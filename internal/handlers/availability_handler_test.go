@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"gorm.io/gorm"
+)
+
+// availabilityDay is the day the availability tests book, far enough in the future.
+var availabilityDay = time.Date(2030, time.January, 7, 0, 0, 0, 0, time.Local)
+
+// createProgram saves a program of the given duration.
+func createProgram(t *testing.T, db *gorm.DB, title string, duration int) models.Program {
+	t.Helper()
+	program := models.Program{Title: title, Duration: duration}
+	if err := db.Create(&program).Error; err != nil {
+		t.Fatal(err)
+	}
+	return program
+}
+
+// openClinic sets the opening hours of the weekday of day.
+func openClinic(t *testing.T, db *gorm.DB, day time.Time, opensAt, closesAt string) {
+	t.Helper()
+	hours := models.OpeningHours{Weekday: int(day.Weekday()), OpensAt: opensAt, ClosesAt: closesAt}
+	if err := db.Create(&hours).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createSpecialist saves a specialist performing the program and working on the weekday of day.
+func createSpecialist(t *testing.T, db *gorm.DB, program models.Program, day time.Time, startsAt, endsAt string) models.Specialist {
+	t.Helper()
+	specialist := models.Specialist{Name: "Specialist " + startsAt, Programs: []models.Program{program}}
+	if err := db.Create(&specialist).Error; err != nil {
+		t.Fatal(err)
+	}
+	schedule := models.SpecialistSchedule{SpecialistID: specialist.ID, Weekday: int(day.Weekday()), StartsAt: startsAt, EndsAt: endsAt}
+	if err := db.Create(&schedule).Error; err != nil {
+		t.Fatal(err)
+	}
+	return specialist
+}
+
+// at returns the time of day on availabilityDay, e.g. at(9, 30).
+func at(hour, minute int) time.Time {
+	return availabilityDay.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+}
+
+// slotStarts returns the start times of the slots as "15:04", with the free specialists.
+func slotStarts(slots []AvailabilitySlot) map[string]int {
+	starts := make(map[string]int, len(slots))
+	for _, slot := range slots {
+		starts[slot.Start.Format("15:04")] = slot.Available
+	}
+	return starts
+}
+
+func assertSlots(t *testing.T, slots []AvailabilitySlot, want map[string]int) {
+	t.Helper()
+	got := slotStarts(slots)
+	if len(got) != len(want) {
+		t.Fatalf("got slots %v, want %v", got, want)
+	}
+	for start, available := range want {
+		if got[start] != available {
+			t.Fatalf("got slots %v, want %v", got, want)
+		}
+	}
+}
+
+func TestComputeAvailabilityWithoutSpecialists(t *testing.T) {
+	db := newTestDB(t)
+	program := createProgram(t, db, "Massage", 60)
+	openClinic(t, db, availabilityDay, "09:00", "11:00")
+	now := availabilityDay.AddDate(0, 0, -1)
+
+	slots, err := computeAvailability(db, program, availabilityDay, availabilityDay, now, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSlots(t, slots, map[string]int{"09:00": 1, "09:30": 1, "10:00": 1})
+
+	// A pending appointment takes every slot it overlaps, a cancelled one none
+	appointments := []models.Appointment{
+		{ProgramID: program.ID, RequestedAt: at(9, 0), Status: models.AppointmentPending},
+		{ProgramID: program.ID, RequestedAt: at(10, 0), Status: models.AppointmentCancelled},
+	}
+	if err := db.Create(&appointments).Error; err != nil {
+		t.Fatal(err)
+	}
+	slots, err = computeAvailability(db, program, availabilityDay, availabilityDay, now, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSlots(t, slots, map[string]int{"10:00": 1})
+}
+
+func TestComputeAvailabilityCountsSpecialists(t *testing.T) {
+	db := newTestDB(t)
+	program := createProgram(t, db, "Physiotherapy", 60)
+	openClinic(t, db, availabilityDay, "08:00", "12:00")
+	createSpecialist(t, db, program, availabilityDay, "09:00", "11:00")
+	late := createSpecialist(t, db, program, availabilityDay, "10:00", "13:00")
+	now := availabilityDay.AddDate(0, 0, -1)
+
+	slots, err := computeAvailability(db, program, availabilityDay, availabilityDay, now, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The late shift is cut at the closing time
+	assertSlots(t, slots, map[string]int{"09:00": 1, "09:30": 1, "10:00": 2, "10:30": 1, "11:00": 1})
+
+	// An absent specialist is not counted
+	exception := models.ScheduleException{SpecialistID: &late.ID, StartsOn: availabilityDay, EndsOn: availabilityDay, Reason: "Vacation"}
+	if err := db.Create(&exception).Error; err != nil {
+		t.Fatal(err)
+	}
+	slots, err = computeAvailability(db, program, availabilityDay, availabilityDay, now, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSlots(t, slots, map[string]int{"09:00": 1, "09:30": 1, "10:00": 1})
+}
+
+func TestComputeAvailabilitySharedSpecialist(t *testing.T) {
+	db := newTestDB(t)
+	massage := createProgram(t, db, "Massage", 60)
+	openClinic(t, db, availabilityDay, "09:00", "11:00")
+	specialist := createSpecialist(t, db, massage, availabilityDay, "09:00", "11:00")
+	// The specialist also performs a second program, booked at 10:00
+	therapy := createProgram(t, db, "Therapy", 30)
+	if err := db.Model(&specialist).Association("Programs").Append(&therapy); err != nil {
+		t.Fatal(err)
+	}
+	appointment := models.Appointment{ProgramID: therapy.ID, RequestedAt: at(10, 0), Status: models.AppointmentConfirmed}
+	if err := db.Create(&appointment).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	slots, err := computeAvailability(db, massage, availabilityDay, availabilityDay, availabilityDay.AddDate(0, 0, -1), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSlots(t, slots, map[string]int{"09:00": 1})
+}
+
+func TestComputeAvailabilityClosedDays(t *testing.T) {
+	db := newTestDB(t)
+	program := createProgram(t, db, "Massage", 60)
+	openClinic(t, db, availabilityDay, "09:00", "11:00")
+	next := availabilityDay.AddDate(0, 0, 1)
+	openClinic(t, db, next, "09:00", "10:00")
+	now := availabilityDay.AddDate(0, 0, -1)
+
+	// A clinic exception closes the first day, the second is closed every week
+	exception := models.ScheduleException{StartsOn: availabilityDay, EndsOn: availabilityDay, Reason: "Holiday"}
+	if err := db.Create(&exception).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.OpeningHours{}).Where("weekday = ?", int(next.Weekday())).Update("closed", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	slots, err := computeAvailability(db, program, availabilityDay, next, now, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSlots(t, slots, map[string]int{})
+}
+
+func TestComputeAvailabilitySkipsPastSlots(t *testing.T) {
+	db := newTestDB(t)
+	program := createProgram(t, db, "Massage", 60)
+	openClinic(t, db, availabilityDay, "09:00", "11:00")
+
+	slots, err := computeAvailability(db, program, availabilityDay, availabilityDay, at(9, 15), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSlots(t, slots, map[string]int{"09:30": 1, "10:00": 1})
+}
+
+func TestCheckSlot(t *testing.T) {
+	db := newTestDB(t)
+	program := createProgram(t, db, "Physiotherapy", 60)
+	openClinic(t, db, availabilityDay, "08:00", "12:00")
+	createSpecialist(t, db, program, availabilityDay, "09:00", "11:00")
+	now := availabilityDay.AddDate(0, 0, -1)
+
+	if err := checkSlot(db, program, at(9, 0), now, 0); err != nil {
+		t.Fatalf("checkSlot of a free slot = %v", err)
+	}
+	// Outside the schedule, off the slot starts and on another day
+	for _, start := range []time.Time{at(8, 0), at(10, 30), at(9, 10), at(9, 0).AddDate(0, 0, 1)} {
+		if err := checkSlot(db, program, start, now, 0); !errors.Is(err, errSlotTaken) {
+			t.Errorf("checkSlot at %s = %v, want %v", start.Format("Mon 15:04"), err, errSlotTaken)
+		}
+	}
+
+	appointment := models.Appointment{ProgramID: program.ID, RequestedAt: at(9, 0), Status: models.AppointmentPending}
+	if err := db.Create(&appointment).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSlot(db, program, at(9, 30), now, 0); !errors.Is(err, errSlotTaken) {
+		t.Errorf("checkSlot of a booked slot = %v, want %v", err, errSlotTaken)
+	}
+	// The appointment being rescheduled does not take the slot from itself
+	if err := checkSlot(db, program, at(9, 30), now, appointment.ID); err != nil {
+		t.Errorf("checkSlot when rescheduling into its own slot = %v", err)
+	}
+
+	// Nothing is free on a day the clinic is closed
+	exception := models.ScheduleException{StartsOn: availabilityDay, EndsOn: availabilityDay, Reason: "Holiday"}
+	if err := db.Create(&exception).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSlot(db, program, at(9, 0), now, appointment.ID); !errors.Is(err, errSlotTaken) {
+		t.Errorf("checkSlot on a closed day = %v, want %v", err, errSlotTaken)
+	}
+}
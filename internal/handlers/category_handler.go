@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errUnknownCategory = errors.New("unknown category")
+
+// errDuplicateCategory is returned for a code or slug of another category, deleted ones included.
+var errDuplicateCategory = errors.New("another category has this code or slug, it may be in the trash")
+
+// CategoryResponse defines the structure of the JSON response for a category.
+type CategoryResponse struct {
+	ID        uint    `json:"pk"`
+	Code      string  `json:"code"`
+	Slug      string  `json:"slug"`
+	Name      string  `json:"name"`
+	NameUK    string  `json:"name_uk"`
+	NamePL    string  `json:"name_pl"`
+	NameEN    string  `json:"name_en"`
+	SortOrder int     `json:"sort_order"`
+	Icon      *string `json:"icon"`
+}
+
+// toCategoryResponse converts a models.Category to a CategoryResponse.
+func toCategoryResponse(category models.Category) CategoryResponse {
+	var icon *string
+	if category.Icon != "" {
+		url := storage.URL(category.Icon)
+		icon = &url
+	}
+	return CategoryResponse{
+		ID:        category.ID,
+		Code:      category.Code,
+		Slug:      category.Slug,
+		Name:      category.Name,
+		NameUK:    category.NameUK,
+		NamePL:    category.NamePL,
+		NameEN:    category.NameEN,
+		SortOrder: category.SortOrder,
+		Icon:      icon,
+	}
+}
+
+// ListCategories is the handler for fetching all categories in display order.
+func ListCategories(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var categories []models.Category
+		if err := db.Order("sort_order asc, code asc").Find(&categories).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch Categories"})
+			return
+		}
+		responses := make([]CategoryResponse, 0, len(categories))
+		for _, category := range categories {
+			responses = append(responses, toCategoryResponse(category))
+		}
+		ctx.JSON(http.StatusOK, responses)
+	}
+}
+
+// GetCategory is the handler for fetching a single category by its ID.
+func GetCategory(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		var category models.Category
+		if err := db.First(&category, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch Category"})
+			}
+			return
+		}
+		ctx.JSON(http.StatusOK, toCategoryResponse(category))
+	}
+}
+
+// CategoryRequest defines the request body for creating or updating a category.
+type CategoryRequest struct {
+	Code      string `json:"code" binding:"required,len=2"`
+	Slug      string `json:"slug" binding:"required,max=100"`
+	Name      string `json:"name" binding:"required,max=150"`
+	NamePL    string `json:"name_pl" binding:"max=150"`
+	NameEN    string `json:"name_en" binding:"max=150"`
+	NameUK    string `json:"name_uk" binding:"max=150"`
+	SortOrder int    `json:"sort_order"`
+	// Path or address of a media library image, "" removes the icon. Kept when omitted
+	Icon *string `json:"icon"`
+	// ID of a media library image, instead of icon
+	IconAsset *uint `json:"icon_asset"`
+}
+
+// apply copies the request fields to the category model.
+func (request CategoryRequest) apply(category *models.Category) {
+	category.Code = strings.ToUpper(request.Code)
+	category.Slug = request.Slug
+	category.Name = request.Name
+	category.NamePL = request.NamePL
+	category.NameEN = request.NameEN
+	category.NameUK = request.NameUK
+	category.SortOrder = request.SortOrder
+	translation.FillDefaults(category)
+}
+
+// CreateCategory is the handler for creating a new category.
+func CreateCategory(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request CategoryRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var category models.Category
+		request.apply(&category)
+		if err := requestImage(db, &category.Icon, request.Icon, request.IconAsset); err != nil {
+			imageError(ctx, err)
+			return
+		}
+		if err := createCategory(db, &category); err != nil {
+			if errors.Is(err, errDuplicateCategory) {
+				ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create Category"})
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &category, nil)
+		ctx.JSON(http.StatusCreated, toCategoryResponse(category))
+	}
+}
+
+// UpdateCategory is the handler for updating a category.
+// Changing the code also updates the programs and prices using the category.
+func UpdateCategory(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		var category models.Category
+		if err := db.First(&category, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database Error"})
+			}
+			return
+		}
+		var request CategoryRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		oldCode, oldIcon := category.Code, category.Icon
+		before := auditState(category)
+		request.apply(&category)
+		if err := requestImage(db, &category.Icon, request.Icon, request.IconAsset); err != nil {
+			imageError(ctx, err)
+			return
+		}
+		if err := saveCategory(db, &category, oldCode); err != nil {
+			if errors.Is(err, errDuplicateCategory) {
+				ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update Category"})
+			return
+		}
+		recordAudit(ctx, db, models.AuditUpdate, &category, before)
+		// A replaced icon nothing else shows is removed
+		releaseImages(db, oldIcon)
+		ctx.JSON(http.StatusOK, toCategoryResponse(category))
+	}
+}
+
+// DeleteCategory is the handler for deleting a category that is not used anymore.
+func DeleteCategory(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		var category models.Category
+		if err := db.First(&category, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database Error"})
+			}
+			return
+		}
+		inUse, err := categoryInUse(db, category.Code)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database Error"})
+			return
+		}
+		if inUse {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Category is used by programs or prices"})
+			return
+		}
+		before := auditState(category)
+		if err := db.Delete(&category).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete Category"})
+			return
+		}
+		recordAudit(ctx, db, models.AuditDelete, &category, before)
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// createCategory saves a new category, returning errDuplicateCategory when its code
+// or slug is taken.
+func createCategory(db *gorm.DB, category *models.Category) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := checkCategoryUnique(tx, category); err != nil {
+			return err
+		}
+		return tx.Create(category).Error
+	})
+}
+
+// saveCategory saves a category and moves programs and prices to the new code when it changed.
+// It returns errDuplicateCategory when the code or slug belongs to another category.
+func saveCategory(db *gorm.DB, category *models.Category, oldCode string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := checkCategoryUnique(tx, category); err != nil {
+			return err
+		}
+		if err := tx.Save(category).Error; err != nil {
+			return err
+		}
+		if oldCode == category.Code {
+			return nil
+		}
+		if err := tx.Model(&models.Program{}).Where("category = ?", oldCode).Update("category", category.Code).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Price{}).Where("category = ?", oldCode).Update("category", category.Code).Error
+	})
+}
+
+// categoryInUse reports whether any program or price references the category code.
+func categoryInUse(db *gorm.DB, code string) (bool, error) {
+	var programs, prices int64
+	if err := db.Model(&models.Program{}).Where("category = ?", code).Count(&programs).Error; err != nil {
+		return false, err
+	}
+	if err := db.Model(&models.Price{}).Where("category = ?", code).Count(&prices).Error; err != nil {
+		return false, err
+	}
+	return programs+prices > 0, nil
+}
+
+// checkCategoryUnique makes sure no other category has the code or slug of a category.
+// Deleted categories count too, the unique indexes cover them and they can be restored.
+func checkCategoryUnique(db *gorm.DB, category *models.Category) error {
+	var count int64
+	err := db.Unscoped().Model(&models.Category{}).
+		Where("(code = ? OR slug = ?) AND id <> ?", category.Code, category.Slug, category.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errDuplicateCategory
+	}
+	return nil
+}
+
+// checkCategory makes sure a category with the given code exists. The code is
+// upper-cased in place, as the categories store it.
+func checkCategory(db *gorm.DB, code *string) error {
+	*code = strings.ToUpper(strings.TrimSpace(*code))
+	var count int64
+	if err := db.Model(&models.Category{}).Where("code = ?", *code).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errUnknownCategory
+	}
+	return nil
+}
+
+// loadCategories returns all categories in display order for the admin forms.
+func loadCategories(db *gorm.DB) []models.Category {
+	var categories []models.Category
+	db.Order("sort_order asc, code asc").Find(&categories)
+	return categories
+}
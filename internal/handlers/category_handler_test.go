@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+)
+
+func TestCategoryDuplicates(t *testing.T) {
+	db := newTestDB(t)
+	first := models.Category{Code: "AB", Slug: "first", Name: "First"}
+	if err := createCategory(db, &first); err != nil {
+		t.Fatal(err)
+	}
+	deleted := models.Category{Code: "CD", Slug: "deleted", Name: "Deleted"}
+	if err := createCategory(db, &deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, category := range []models.Category{
+		{Code: "AB", Slug: "other", Name: "Same code"},
+		{Code: "EF", Slug: "first", Name: "Same slug"},
+		{Code: "CD", Slug: "other", Name: "Code in the trash"},
+		{Code: "EF", Slug: "deleted", Name: "Slug in the trash"},
+	} {
+		if err := createCategory(db, &category); !errors.Is(err, errDuplicateCategory) {
+			t.Errorf("createCategory(%s) = %v, want %v", category.Name, err, errDuplicateCategory)
+		}
+	}
+
+	// A category keeps its own code and slug, but can not take those of another
+	first.Name = "Renamed"
+	if err := saveCategory(db, &first, first.Code); err != nil {
+		t.Errorf("saveCategory with its own code = %v", err)
+	}
+	first.Code = "CD"
+	if err := saveCategory(db, &first, "AB"); !errors.Is(err, errDuplicateCategory) {
+		t.Errorf("saveCategory with a code in the trash = %v, want %v", err, errDuplicateCategory)
+	}
+}
+
+func TestCheckCategoryUpperCases(t *testing.T) {
+	db := newTestDB(t)
+	if err := createCategory(db, &models.Category{Code: "AB", Slug: "ab", Name: "AB"}); err != nil {
+		t.Fatal(err)
+	}
+	code := " ab"
+	if err := checkCategory(db, &code); err != nil {
+		t.Fatalf("checkCategory(%q) = %v", " ab", err)
+	}
+	if code != "AB" {
+		t.Errorf("code = %q, want %q", code, "AB")
+	}
+	code = "zz"
+	if err := checkCategory(db, &code); !errors.Is(err, errUnknownCategory) {
+		t.Errorf("checkCategory(%q) = %v, want %v", "zz", err, errUnknownCategory)
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"net/http"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// Where the CSRF token is sent back: HTMX requests carry the header, set by
+// hx-headers in the layout, plain forms the hidden field.
+const (
+	csrfHeader = "X-CSRF-Token"
+	csrfField  = "csrf_token"
+)
+
+// CSRFProtect is a middleware that keeps a random token in the session and rejects
+// the requests changing data that do not send it back. Another site can make the
+// browser send the session cookie, but can not read the token from the pages.
+func CSRFProtect() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		token, _ := session.Get("csrfToken").(string)
+		if token == "" {
+			token = newCSRFToken(ctx)
+			if token == "" {
+				ctx.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+		}
+		ctx.Set("csrfToken", token)
+
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			ctx.Next()
+			return
+		}
+		sent := ctx.GetHeader(csrfHeader)
+		if sent == "" {
+			sent = ctx.PostForm(csrfField)
+		}
+		if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			log.Printf("Rejected %s %s with a missing or wrong CSRF token", ctx.Request.Method, ctx.Request.URL.Path)
+			if ctx.GetHeader("HX-Request") == "true" {
+				// The page holds an old token, e.g. from before a logout, reloading gets the current one
+				ctx.Header("HX-Refresh", "true")
+			}
+			ctx.HTML(http.StatusForbidden, "403.html", gin.H{
+				"Title":     "Forbidden",
+				"CSRFToken": csrfToken(ctx),
+				"Message":   "The form has expired or was sent from another site. Reload the page and try again.",
+			})
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// newCSRFToken makes a new token and keeps it in the session, e.g. after a login.
+// It returns an empty token when no random bytes can be read.
+func newCSRFToken(ctx *gin.Context) string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("Failed to generate CSRF token: %s", err)
+		return ""
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	session := sessions.Default(ctx)
+	session.Set("csrfToken", token)
+	if err := session.Save(); err != nil {
+		log.Printf("Failed to save CSRF token: %s", err)
+	}
+	ctx.Set("csrfToken", token)
+	return token
+}
+
+// csrfToken returns the CSRF token of the session, for the layout and the plain forms.
+func csrfToken(ctx *gin.Context) string {
+	return ctx.GetString("csrfToken")
+}
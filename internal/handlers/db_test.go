@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/database"
+	"github.com/DmytroPI-dev/clinic-golang/internal/migrations"
+	"gorm.io/gorm"
+)
+
+// newTestDB returns an in-memory SQLite database with every migration applied.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.DB_Connect(database.DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.New(db).Up(0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/database"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Page sizes of the list endpoints, used when ?limit= is missing or too big.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// PaginatedResponse matches the top-level paginated Django structure.
+// Results hold the response items of the list endpoint.
+type PaginatedResponse struct {
+	Count    int64   `json:"count"`
+	Next     *string `json:"next"`
+	Previous *string `json:"previous"`
+	Results  any     `json:"results"`
+}
+
+// listOptions describes the filters and orderings a list endpoint supports.
+type listOptions struct {
+	pageSize int
+	// Columns matched by ?search=
+	searchColumns []string
+	// Names accepted by ?ordering=, mapped to their columns
+	orderings map[string]string
+	// Ordering used without ?ordering=, in the same format, e.g. "-posted_on"
+	defaultOrder string
+	// Enables the ?category= filter
+	category bool
+	// Enables ?min_price= and ?max_price= on this column
+	priceColumn string
+	// Optional condition wrapping the price comparison, with %s in its place
+	priceCondition string
+}
+
+// listQuery is the parsed pagination, filtering and ordering query of a list request,
+// e.g. ?page=2&limit=10&category=KS&search=laser&ordering=price,-created_at&min_price=100
+type listQuery struct {
+	ctx            *gin.Context
+	limit          int
+	page           int
+	category       string
+	search         string
+	searchColumns  []string
+	minPrice       *float64
+	maxPrice       *float64
+	priceColumn    string
+	priceCondition string
+	order          []orderColumn
+}
+
+// orderColumn is a single column of the ORDER BY clause.
+type orderColumn struct {
+	column string
+	desc   bool
+}
+
+// parseListQuery reads the list query of a request. Unknown orderings and
+// malformed prices are reported as errors, bad page numbers fall back to defaults.
+func parseListQuery(ctx *gin.Context, options listOptions) (listQuery, error) {
+	query := listQuery{
+		ctx:            ctx,
+		limit:          options.pageSize,
+		page:           1,
+		search:         strings.TrimSpace(ctx.Query("search")),
+		searchColumns:  options.searchColumns,
+		priceColumn:    options.priceColumn,
+		priceCondition: options.priceCondition,
+	}
+	if query.limit == 0 {
+		query.limit = defaultPageSize
+	}
+	if limit, err := strconv.Atoi(ctx.Query("limit")); err == nil && limit > 0 {
+		query.limit = min(limit, maxPageSize)
+	}
+	if page, err := strconv.Atoi(ctx.Query("page")); err == nil && page > 0 {
+		query.page = page
+	}
+	if options.category {
+		query.category = strings.ToUpper(strings.TrimSpace(ctx.Query("category")))
+	}
+	if options.priceColumn != "" {
+		var err error
+		if query.minPrice, err = parsePriceQuery(ctx, "min_price"); err != nil {
+			return query, err
+		}
+		if query.maxPrice, err = parsePriceQuery(ctx, "max_price"); err != nil {
+			return query, err
+		}
+	}
+
+	ordering := ctx.Query("ordering")
+	if ordering == "" {
+		ordering = options.defaultOrder
+	}
+	var err error
+	if query.order, err = parseOrdering(ordering, options.orderings); err != nil {
+		return query, err
+	}
+	// The ID keeps the order of equal rows stable between pages
+	query.order = append(query.order, orderColumn{column: "id"})
+	return query, nil
+}
+
+// parseOrdering reads comma separated ordering names, a leading "-" sorts descending.
+func parseOrdering(ordering string, orderings map[string]string) ([]orderColumn, error) {
+	var order []orderColumn
+	for _, name := range strings.Split(ordering, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		name, desc := strings.CutPrefix(name, "-")
+		column, ok := orderings[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown ordering %q", name)
+		}
+		order = append(order, orderColumn{column: column, desc: desc})
+	}
+	return order, nil
+}
+
+// parsePriceQuery reads an optional non-negative price from the query string.
+func parsePriceQuery(ctx *gin.Context, key string) (*float64, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative number", key)
+	}
+	return &price, nil
+}
+
+// filter applies the filters of the query. It is used for both counting and fetching.
+func (q listQuery) filter(tx *gorm.DB) *gorm.DB {
+	if q.category != "" {
+		tx = tx.Where("category = ?", q.category)
+	}
+	if q.search != "" && len(q.searchColumns) > 0 {
+		pattern := "%" + database.EscapeLike(q.search) + "%"
+		conditions := make([]string, 0, len(q.searchColumns))
+		args := make([]any, 0, len(q.searchColumns))
+		for _, column := range q.searchColumns {
+			conditions = append(conditions, database.Like(tx, column))
+			args = append(args, pattern)
+		}
+		tx = tx.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+	// Both bounds go into one condition, so they match the same price
+	var comparisons []string
+	var prices []any
+	if q.minPrice != nil {
+		comparisons = append(comparisons, q.priceColumn+" >= ?")
+		prices = append(prices, *q.minPrice)
+	}
+	if q.maxPrice != nil {
+		comparisons = append(comparisons, q.priceColumn+" <= ?")
+		prices = append(prices, *q.maxPrice)
+	}
+	if len(comparisons) > 0 {
+		condition := strings.Join(comparisons, " AND ")
+		if q.priceCondition != "" {
+			condition = fmt.Sprintf(q.priceCondition, condition)
+		}
+		tx = tx.Where(condition, prices...)
+	}
+	return tx
+}
+
+// paginate applies the filters, the ordering and the requested page.
+func (q listQuery) paginate(tx *gorm.DB) *gorm.DB {
+	tx = q.filter(tx)
+	for _, order := range q.order {
+		tx = tx.Order(database.OrderBy(tx, order.column, order.desc))
+	}
+	return tx.Limit(q.limit).Offset((q.page - 1) * q.limit)
+}
+
+// count returns the number of rows matching the filters.
+func (q listQuery) count(db *gorm.DB, model any) (int64, error) {
+	var count int64
+	err := db.Model(model).Scopes(q.filter).Count(&count).Error
+	return count, err
+}
+
+// response wraps a page of results with the count and the links to the other pages.
+func (q listQuery) response(count int64, results any) PaginatedResponse {
+	response := PaginatedResponse{Count: count, Results: results}
+	if int64(q.page)*int64(q.limit) < count {
+		response.Next = q.pageURL(q.page + 1)
+	}
+	if q.page > 1 {
+		response.Previous = q.pageURL(q.page - 1)
+	}
+	return response
+}
+
+// pageURL returns the absolute URL of another page, keeping the other query parameters.
+func (q listQuery) pageURL(page int) *string {
+	scheme := "http"
+	if q.ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	values := q.ctx.Request.URL.Query()
+	values.Set("limit", strconv.Itoa(q.limit))
+	values.Set("page", strconv.Itoa(page))
+	url := fmt.Sprintf("%s://%s%s?%s", scheme, q.ctx.Request.Host, q.ctx.Request.URL.Path, values.Encode())
+	return &url
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"log"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"gorm.io/gorm"
+)
+
+// Backoff of the login form. After the free attempts every failed login of the
+// same user name or address doubles the wait before the next one, starting at
+// a second. Addresses get more free attempts, people may share one in an office.
+const (
+	loginWindow      = 15 * time.Minute
+	userFreeAttempts = 3
+	ipFreeAttempts   = 10
+	maxLoginDelay    = 15 * time.Minute
+)
+
+// LoginLimits lock the accounts whose passwords are being guessed.
+type LoginLimits struct {
+	// Wrong passwords in a row that lock an account, 0 never locks
+	MaxFailures int
+	// How long a locked account stays locked
+	Lockout time.Duration
+}
+
+// loginDelay returns how long a user name and an address have to wait before the
+// next login, counting their failed logins of the last loginWindow.
+func loginDelay(db *gorm.DB, userName, ip string, now time.Time) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range []struct {
+		column, value string
+		free          int
+	}{
+		{"user_name", userName, userFreeAttempts},
+		{"ip", ip, ipFreeAttempts},
+	} {
+		recent := db.Model(&models.LoginAttempt{}).Where(key.column+" = ? AND created_at > ?", key.value, now.Add(-loginWindow))
+		var failed int64
+		if err := recent.Count(&failed).Error; err != nil {
+			return 0, err
+		}
+		if failed < int64(key.free) {
+			continue
+		}
+		var last models.LoginAttempt
+		if err := recent.Order("created_at desc").First(&last).Error; err != nil {
+			return 0, err
+		}
+		if left := last.CreatedAt.Add(loginBackoff(failed - int64(key.free))).Sub(now); left > wait {
+			wait = left
+		}
+	}
+	return wait, nil
+}
+
+// loginBackoff returns the wait after the given number of failed logins past the free ones.
+func loginBackoff(failed int64) time.Duration {
+	if failed >= 20 {
+		return maxLoginDelay
+	}
+	return min(time.Second<<failed, maxLoginDelay)
+}
+
+// recordFailedLogin saves a failed login. user is nil when no user has the given name.
+func recordFailedLogin(db *gorm.DB, userName, ip string, user *models.User) {
+	attempt := models.LoginAttempt{UserName: userName, IP: ip}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+	if err := db.Create(&attempt).Error; err != nil {
+		log.Printf("Failed to save failed login of %s from %s: %s", userName, ip, err)
+	}
+}
+
+// countFailedLogin counts a wrong password of a user, locking the account once
+// limits.MaxFailures are reached. The counter is updated in the database, so
+// simultaneous logins are all counted.
+func countFailedLogin(db *gorm.DB, user *models.User, limits LoginLimits, now time.Time) {
+	counted := db.Model(user)
+	if user.LockedUntil != nil && !user.IsLocked(now) {
+		// The lockout is over, counting starts again
+		counted = counted.Updates(map[string]any{"failed_logins": 1, "locked_until": nil})
+	} else {
+		counted = counted.Update("failed_logins", gorm.Expr("failed_logins + 1"))
+	}
+	if counted.Error != nil {
+		log.Printf("Failed to count failed login of user %d: %s", user.ID, counted.Error)
+		return
+	}
+	if limits.MaxFailures <= 0 {
+		return
+	}
+	until := now.Add(limits.Lockout)
+	locked := db.Model(&models.User{}).
+		Where("id = ? AND failed_logins >= ? AND (locked_until IS NULL OR locked_until <= ?)", user.ID, limits.MaxFailures, now).
+		Update("locked_until", until)
+	if locked.Error != nil {
+		log.Printf("Failed to lock user %d: %s", user.ID, locked.Error)
+		return
+	}
+	if locked.RowsAffected > 0 {
+		log.Printf("Locked user %s until %s after %d failed logins", user.UserName, until.Format(time.RFC3339), limits.MaxFailures)
+	}
+}
+
+// unlockUser lets a locked out user log in again right away, clearing the lockout
+// and the recent failed logins of the user that would still slow the login down.
+func unlockUser(db *gorm.DB, user *models.User, now time.Time) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("(user_id = ? OR user_name = ?) AND created_at > ?", user.ID, user.UserName, now.Add(-loginWindow)).
+			Delete(&models.LoginAttempt{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(user).Updates(map[string]any{"failed_logins": 0, "locked_until": nil}).Error
+	})
+	if err != nil {
+		return err
+	}
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	return nil
+}
+
+// resetFailedLogins clears the failed logins of a user after a successful one.
+func resetFailedLogins(db *gorm.DB, user *models.User) {
+	if user.FailedLogins == 0 && user.LockedUntil == nil {
+		return
+	}
+	if err := db.Model(user).Updates(map[string]any{"failed_logins": 0, "locked_until": nil}).Error; err != nil {
+		log.Printf("Failed to reset failed logins of user %d: %s", user.ID, err)
+	}
+}
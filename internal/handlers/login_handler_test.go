@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"gorm.io/gorm"
+)
+
+// createUser saves an admin user with the given name.
+func createUser(t *testing.T, db *gorm.DB, userName string) models.User {
+	t.Helper()
+	user := models.User{UserName: userName, Email: userName + "@example.com", Role: models.Admin}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// reloadUser loads a user again, into a new struct so no old value is kept.
+func reloadUser(t *testing.T, db *gorm.DB, id uint) models.User {
+	t.Helper()
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// failLogins saves failed logins made at the given times.
+func failLogins(t *testing.T, db *gorm.DB, userName, ip string, times ...time.Time) {
+	t.Helper()
+	for _, at := range times {
+		attempt := models.LoginAttempt{CreatedAt: at, UserName: userName, IP: ip}
+		if err := db.Create(&attempt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoginBackoff(t *testing.T) {
+	for failed, want := range map[int64]time.Duration{
+		0:  time.Second,
+		1:  2 * time.Second,
+		4:  16 * time.Second,
+		10: maxLoginDelay,
+		64: maxLoginDelay,
+	} {
+		if got := loginBackoff(failed); got != want {
+			t.Errorf("loginBackoff(%d) = %s, want %s", failed, got, want)
+		}
+	}
+}
+
+func TestLoginDelayByUserName(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+
+	// The free attempts do not slow down the next login
+	failLogins(t, db, "alice", "10.0.0.1", now.Add(-3*time.Second), now.Add(-2*time.Second))
+	if wait, err := loginDelay(db, "alice", "10.0.0.1", now); err != nil || wait != 0 {
+		t.Fatalf("got wait %s, %v after the free attempts", wait, err)
+	}
+
+	// Every further failure doubles the wait, counted from the last one
+	failLogins(t, db, "alice", "10.0.0.2", now.Add(-time.Second))
+	if wait, err := loginDelay(db, "alice", "10.0.0.3", now); err != nil || wait != 0 {
+		t.Fatalf("got wait %s, %v after the first backoff passed", wait, err)
+	}
+	failLogins(t, db, "alice", "10.0.0.2", now)
+	if wait, err := loginDelay(db, "alice", "10.0.0.3", now); err != nil || wait != 2*time.Second {
+		t.Fatalf("got wait %s, %v, want 2s", wait, err)
+	}
+
+	// Other user names are not slowed down
+	if wait, err := loginDelay(db, "bob", "10.0.0.3", now); err != nil || wait != 0 {
+		t.Fatalf("got wait %s, %v for another user", wait, err)
+	}
+}
+
+func TestLoginDelayByAddress(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+
+	// An address guessing many user names is slowed down after its free attempts
+	for i := range ipFreeAttempts + 1 {
+		failLogins(t, db, string(rune('a'+i)), "10.0.0.1", now.Add(-time.Duration(ipFreeAttempts-i)*time.Millisecond))
+	}
+	if wait, err := loginDelay(db, "zoe", "10.0.0.1", now); err != nil || wait != 2*time.Second {
+		t.Fatalf("got wait %s, %v, want 2s", wait, err)
+	}
+	if wait, err := loginDelay(db, "zoe", "10.0.0.2", now); err != nil || wait != 0 {
+		t.Fatalf("got wait %s, %v for another address", wait, err)
+	}
+}
+
+func TestLoginDelayForgetsOldAttempts(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	old := now.Add(-loginWindow - time.Minute)
+	failLogins(t, db, "alice", "10.0.0.1", old, old, old, old, old, old)
+	if wait, err := loginDelay(db, "alice", "10.0.0.1", now); err != nil || wait != 0 {
+		t.Fatalf("got wait %s, %v for attempts outside the window", wait, err)
+	}
+}
+
+func TestCountFailedLoginLocksAccount(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "alice")
+	limits := LoginLimits{MaxFailures: 3, Lockout: 15 * time.Minute}
+	now := time.Now()
+
+	for range limits.MaxFailures - 1 {
+		countFailedLogin(db, &user, limits, now)
+	}
+	user = reloadUser(t, db, user.ID)
+	if user.FailedLogins != limits.MaxFailures-1 || user.IsLocked(now) {
+		t.Fatalf("got %d failures, locked %v before the limit", user.FailedLogins, user.IsLocked(now))
+	}
+
+	countFailedLogin(db, &user, limits, now)
+	user = reloadUser(t, db, user.ID)
+	if !user.IsLocked(now) || !user.LockedUntil.Equal(now.Add(limits.Lockout)) {
+		t.Fatalf("got locked until %v, want %v", user.LockedUntil, now.Add(limits.Lockout))
+	}
+
+	// More failures while locked do not extend the lockout
+	countFailedLogin(db, &user, limits, now.Add(time.Minute))
+	user = reloadUser(t, db, user.ID)
+	if !user.LockedUntil.Equal(now.Add(limits.Lockout)) {
+		t.Fatalf("lockout was extended to %v", user.LockedUntil)
+	}
+
+	// Once the lockout is over counting starts again
+	later := now.Add(limits.Lockout + time.Minute)
+	countFailedLogin(db, &user, limits, later)
+	user = reloadUser(t, db, user.ID)
+	if user.FailedLogins != 1 || user.LockedUntil != nil {
+		t.Fatalf("got %d failures, locked until %v after the lockout", user.FailedLogins, user.LockedUntil)
+	}
+}
+
+func TestCountFailedLoginWithoutLimit(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "alice")
+	now := time.Now()
+	for range 10 {
+		countFailedLogin(db, &user, LoginLimits{}, now)
+	}
+	user = reloadUser(t, db, user.ID)
+	if user.FailedLogins != 10 || user.LockedUntil != nil {
+		t.Fatalf("got %d failures, locked until %v without a limit", user.FailedLogins, user.LockedUntil)
+	}
+}
+
+func TestResetFailedLogins(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "alice")
+	limits := LoginLimits{MaxFailures: 1, Lockout: time.Minute}
+	countFailedLogin(db, &user, limits, time.Now())
+	user = reloadUser(t, db, user.ID)
+
+	resetFailedLogins(db, &user)
+	user = reloadUser(t, db, user.ID)
+	if user.FailedLogins != 0 || user.LockedUntil != nil {
+		t.Fatalf("got %d failures, locked until %v after the reset", user.FailedLogins, user.LockedUntil)
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "alice")
+	now := time.Now()
+	limits := LoginLimits{MaxFailures: 3, Lockout: time.Hour}
+	for range limits.MaxFailures {
+		recordFailedLogin(db, user.UserName, "192.0.2.1", &user)
+		countFailedLogin(db, &user, limits, now)
+	}
+	// Guesses of the name before the user existed, and of another name
+	failLogins(t, db, "alice", "192.0.2.2", now.Add(-time.Minute))
+	failLogins(t, db, "bob", "192.0.2.2", now.Add(-time.Minute))
+	// Kept for the purge job
+	failLogins(t, db, "alice", "192.0.2.2", now.Add(-time.Hour))
+	user = reloadUser(t, db, user.ID)
+
+	if err := unlockUser(db, &user, now); err != nil {
+		t.Fatal(err)
+	}
+	user = reloadUser(t, db, user.ID)
+	if user.FailedLogins != 0 || user.LockedUntil != nil {
+		t.Fatalf("got %d failures, locked until %v after the unlock", user.FailedLogins, user.LockedUntil)
+	}
+	if wait, err := loginDelay(db, "alice", "192.0.2.3", now); err != nil || wait != 0 {
+		t.Fatalf("loginDelay after the unlock = %s, %v, want no wait", wait, err)
+	}
+	var left []string
+	if err := db.Model(&models.LoginAttempt{}).Order("user_name").Pluck("user_name", &left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 2 || left[0] != "alice" || left[1] != "bob" {
+		t.Fatalf("attempts left after the unlock: %v, want the old one of alice and the one of bob", left)
+	}
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errUnknownImage is returned for an image of an API request that is not in the media library.
+var errUnknownImage = errors.New("image must be the path, address or ID of a media library image")
+
+// ImageResponse is an uploaded image with its resized copies, ready for a <picture> element:
+// a <source> per entry of Sources, with URL as the <img> fallback.
+type ImageResponse struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	// One source per format, WebP first
+	Sources []ImageSource `json:"sources,omitempty"`
+}
+
+// ImageSource lists the copies of an image in one format,
+// e.g. {"type": "image/webp", "srcset": "/uploads/a-400w.webp 400w, /uploads/a.webp 800w"}
+type ImageSource struct {
+	Type   string `json:"type"`
+	Srcset string `json:"srcset"`
+}
+
+// imageSet holds the media library assets of the images of a response, by path.
+type imageSet map[string]models.MediaAsset
+
+// loadImages loads the assets and variants of the given image paths.
+// Images missing from the media library are answered without variants.
+func loadImages(db *gorm.DB, paths ...string) imageSet {
+	images := imageSet{}
+	var assets []models.MediaAsset
+	if err := db.Preload("Variants").Where("path IN ?", paths).Find(&assets).Error; err != nil {
+		log.Printf("Failed to load image variants: %s", err)
+		return images
+	}
+	for _, asset := range assets {
+		images[asset.Path] = asset
+	}
+	return images
+}
+
+// response returns the response of an image, nil when there is no image.
+func (s imageSet) response(path string) *ImageResponse {
+	if path == "" {
+		return nil
+	}
+	response := &ImageResponse{URL: storage.URL(path)}
+	asset, ok := s[path]
+	if !ok {
+		return response
+	}
+	response.Width, response.Height = asset.Width, asset.Height
+
+	// Smallest copies first within a srcset, WebP before the original format
+	variants := append([]models.MediaVariant(nil), asset.Variants...)
+	sort.Slice(variants, func(i, j int) bool {
+		if variants[i].Format != variants[j].Format {
+			return variants[i].Format == "webp" || variants[j].Format != "webp" && variants[i].Format < variants[j].Format
+		}
+		return variants[i].Width < variants[j].Width
+	})
+	for _, variant := range variants {
+		candidate := fmt.Sprintf("%s %dw", storage.URL(variant.Path), variant.Width)
+		last := len(response.Sources) - 1
+		if last >= 0 && response.Sources[last].Type == variant.MimeType() {
+			response.Sources[last].Srcset = strings.Join([]string{response.Sources[last].Srcset, candidate}, ", ")
+			continue
+		}
+		response.Sources = append(response.Sources, ImageSource{Type: variant.MimeType(), Srcset: candidate})
+	}
+	return response
+}
+
+// requestImage sets image to the image of an API request, given by the path or the
+// public address of a media library image, as the responses show it, or by the ID of
+// its asset. An empty value removes the image, which is kept when neither is given.
+func requestImage(db *gorm.DB, image *string, value *string, assetID *uint) error {
+	var asset models.MediaAsset
+	switch {
+	case assetID != nil:
+		if err := db.First(&asset, *assetID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errUnknownImage
+			}
+			return err
+		}
+	case value == nil:
+		return nil
+	case *value == "":
+		*image = ""
+		return nil
+	default:
+		err := db.Where("path = ?", uploadPath(*value)).First(&asset).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errUnknownImage
+			}
+			return err
+		}
+	}
+	*image = asset.Path
+	return nil
+}
+
+// imageError answers a failed requestImage, 400 for an image not in the media library.
+func imageError(ctx *gin.Context, err error) {
+	if errors.Is(err, errUnknownImage) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database Error"})
+}
+
+// uploadPath returns the path of an upload given its path or its public address.
+func uploadPath(value string) string {
+	if _, err := storage.Name(value); err == nil {
+		return value
+	}
+	name, err := url.PathUnescape(path.Base(value))
+	if err != nil || storage.URL(storage.Path(name)) != value {
+		return value
+	}
+	return storage.Path(name)
+}
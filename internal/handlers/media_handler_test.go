@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+)
+
+func TestRequestImage(t *testing.T) {
+	db := newTestDB(t)
+	previous := storage.Current()
+	t.Cleanup(func() { storage.Configure(previous) })
+	storage.Configure(storage.NewLocal(t.TempDir(), "https://cdn.example.com/uploads"))
+	asset := models.MediaAsset{Path: storage.Path("a photo.jpg")}
+	if err := db.Create(&asset).Error; err != nil {
+		t.Fatal(err)
+	}
+	text := func(value string) *string { return &value }
+	unknownID := asset.ID + 1
+
+	for _, test := range []struct {
+		name    string
+		value   *string
+		assetID *uint
+		want    string
+		err     error
+	}{
+		{"omitted", nil, nil, "/uploads/old.jpg", nil},
+		{"removed", text(""), nil, "", nil},
+		{"path", text(asset.Path), nil, asset.Path, nil},
+		{"address", text(storage.URL(asset.Path)), nil, asset.Path, nil},
+		{"asset", nil, &asset.ID, asset.Path, nil},
+		{"unknown path", text("/uploads/other.jpg"), nil, "/uploads/old.jpg", errUnknownImage},
+		{"other address", text("https://example.com/a%20photo.jpg"), nil, "/uploads/old.jpg", errUnknownImage},
+		{"unknown asset", nil, &unknownID, "/uploads/old.jpg", errUnknownImage},
+	} {
+		image := "/uploads/old.jpg"
+		err := requestImage(db, &image, test.value, test.assetID)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+		}
+		if image != test.want {
+			t.Errorf("%s: got image %q, want %q", test.name, image, test.want)
+		}
+	}
+}
@@ -1,12 +1,11 @@
 package handler
 
 import (
-	"fmt"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
 	"github.com/DmytroPI-dev/clinic-golang/internal/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -35,28 +34,33 @@ type NewsResponse struct {
 	FeaturesEN    string          `json:"features_en"`
 	PostedOn      utils.ShortDate `json:"posted_on"`
 	// Pointers are used for fields that can be null
-	ImageLeft  *string `json:"image_left,omitempty"`
-	ImageRight *string `json:"image_right,omitempty"`
+	ImageLeft  *ImageResponse `json:"image_left,omitempty"`
+	ImageRight *ImageResponse `json:"image_right,omitempty"`
+	// Publication status and schedule, see models.AllNewsStatuses
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time `json:"unpublish_at,omitempty"`
+	// Languages without their own fields, e.g. {"de": {"title": "..."}}
+	Translations translation.Values `json:"translations,omitempty"`
 }
 
-// PaginatedNewsResponse matches the top-level paginated Django structure.
-type PaginatedNewsResponse struct {
-	Count    int64          `json:"count"`
-	Next     *string        `json:"next"`
-	Previous *string        `json:"previous"`
-	Results  []NewsResponse `json:"results"`
+// LocalizedNewsResponse is the compact view of a news in a single language,
+// returned for ?lang=<code> or a matching Accept-Language header.
+type LocalizedNewsResponse struct {
+	ID          uint            `json:"pk"`
+	Language    string          `json:"language"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Header      string          `json:"header"`
+	Features    string          `json:"features"`
+	PostedOn    utils.ShortDate `json:"posted_on"`
+	ImageLeft   *ImageResponse  `json:"image_left,omitempty"`
+	ImageRight  *ImageResponse  `json:"image_right,omitempty"`
 }
 
 // toNewsResponse converts a models.News to a NewsResponse.
-func toNewsResponse(news models.News) NewsResponse {
-	var imgLeft, imgRight *string
-	if news.ImageLeft != "" {
-		imgLeft = &news.ImageLeft
-	}
-	if news.ImageRight != "" {
-		imgRight = &news.ImageRight
-	}
-
+// images holds the variants of the news images, see newsImages.
+func toNewsResponse(news models.News, images imageSet) NewsResponse {
 	return NewsResponse{
 		ID:            news.ID,
 		Title:         news.Title,
@@ -76,64 +80,126 @@ func toNewsResponse(news models.News) NewsResponse {
 		FeaturesPL:    news.FeaturesPL,
 		FeaturesEN:    news.FeaturesEN,
 		PostedOn:      utils.ShortDate(news.PostedOn),
-		ImageLeft:     imgLeft,
-		ImageRight:    imgRight,
+		ImageLeft:     images.response(news.ImageLeft),
+		ImageRight:    images.response(news.ImageRight),
+		Status:        news.Status,
+		PublishAt:     news.PublishAt,
+		UnpublishAt:   news.UnpublishAt,
+	}
+}
+
+// newsImages loads the variants of the images of the given news.
+func newsImages(db *gorm.DB, news ...models.News) imageSet {
+	paths := make([]string, 0, 2*len(news))
+	for _, item := range news {
+		paths = append(paths, item.ImageLeft, item.ImageRight)
 	}
+	return loadImages(db, paths...)
+}
+
+// publishedNews limits a query to the news shown on the site: published ones, and
+// scheduled ones whose publication time has passed, until their unpublish time.
+// Scheduled news are matched too, so they appear on time between scheduler runs.
+func publishedNews(tx *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return tx.Where("(status = ? OR (status = ? AND publish_at <= ?)) AND (unpublish_at IS NULL OR unpublish_at > ?)",
+		models.NewsPublished, models.NewsScheduled, now, now)
+}
+
+// newsSchedule copies the status and the publication times of a request onto a news.
+// An empty status keeps the current one.
+func newsSchedule(news *models.News, status string, publishAt, unpublishAt *time.Time) error {
+	if status != "" {
+		news.Status = status
+	}
+	news.PublishAt = publishAt
+	news.UnpublishAt = unpublishAt
+	return news.CheckSchedule()
+}
+
+// toLocalizedNewsResponse converts a models.News to a LocalizedNewsResponse.
+func toLocalizedNewsResponse(news models.News, images imageSet, newsLocalizer localizer) LocalizedNewsResponse {
+	full := toNewsResponse(news, images)
+	texts := newsLocalizer.texts(&news)
+	return LocalizedNewsResponse{
+		ID:          news.ID,
+		Language:    newsLocalizer.lang,
+		Title:       texts["title"],
+		Description: texts["description"],
+		Header:      texts["header"],
+		Features:    texts["features"],
+		PostedOn:    full.PostedOn,
+		ImageLeft:   full.ImageLeft,
+		ImageRight:  full.ImageRight,
+	}
+}
+
+// newsListOptions are the filters and orderings supported by ListNews.
+// A page holds a single news unless ?limit= is given.
+var newsListOptions = listOptions{
+	pageSize:      1,
+	searchColumns: []string{"title", "title_pl", "title_en", "title_uk", "header", "description"},
+	orderings: map[string]string{
+		"id":         "id",
+		"title":      "title",
+		"posted_on":  "posted_on",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	defaultOrder: "-posted_on",
 }
 
-// ListNews is the handler for fetching all News.
+// ListNews is the handler for fetching a page of News.
 // It accepts the GORM database connection as an argument.
 func ListNews(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		// Get pagination parameters from the query string (e.g., ?limit=10&page=1)
-		limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "1"))
-		page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-		offset := (page - 1) * limit
-		// Get total number of News
-		var count int64
-		if err := db.Model(&models.News{}).Count(&count).Error; err != nil {
+		// Get pagination, search and ordering from the query string (e.g., ?limit=10&page=1)
+		list, err := parseListQuery(ctx, newsListOptions)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		lang, err := responseLanguage(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Get total number of matching News, drafts and archived news are left out
+		count, err := list.count(db.Scopes(publishedNews), &models.News{})
+		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count News"})
 			return
 		}
 		// Fetching paginated list of News from the database.
 		var newsItems []models.News
-		if err := db.Limit(limit).Offset(offset).Order("posted_on desc").Find(&newsItems).Error; err != nil {
+		if err := db.Scopes(publishedNews, list.paginate).Find(&newsItems).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch News"})
 			return
 		}
 		// Mapping the database models to our responce structs.
-		results := make([]NewsResponse, 0, len(newsItems))
+		ids := make([]uint, 0, len(newsItems))
 		for _, item := range newsItems {
-			results = append(results, toNewsResponse(item))
+			ids = append(ids, item.ID)
 		}
-		// Build paginated response object
-		var nextURL, prevURL *string
-
-		//Detect the absolute URL
-		scheme := "http"
-		if ctx.Request.TLS != nil {
-			scheme = "https"
-		}
-		host := ctx.Request.Host	
-		baseURL := fmt.Sprintf("%s://%s/api/v1/news?limit=%d", scheme, host, limit)
-
-		if int64(page)*int64(limit) < count {
-			url := fmt.Sprintf("%s&page=%d", baseURL, page+1)
-			nextURL = &url
-		}
-		if page > 1 {
-			url := fmt.Sprintf("%s&page=%d", baseURL, page-1)
-			prevURL = &url
+		images := newsImages(db, newsItems...)
+		if lang != "" {
+			// A single language gets the compact view
+			newsLocalizer := newLocalizer(db, &models.News{}, ids, lang)
+			localized := make([]LocalizedNewsResponse, 0, len(newsItems))
+			for _, item := range newsItems {
+				localized = append(localized, toLocalizedNewsResponse(item, images, newsLocalizer))
+			}
+			ctx.JSON(http.StatusOK, list.response(count, localized))
+			return
 		}
-
-		response := PaginatedNewsResponse{
-			Count:    count,
-			Next:     nextURL,
-			Previous: prevURL,
-			Results:  results,
+		extra := loadExtraTranslations(db, &models.News{}, ids)
+		results := make([]NewsResponse, 0, len(newsItems))
+		for _, item := range newsItems {
+			result := toNewsResponse(item, images)
+			result.Translations = extra[item.ID]
+			results = append(results, result)
 		}
-
-		ctx.JSON(http.StatusOK, response)
+		ctx.JSON(http.StatusOK, list.response(count, results))
 	}
 }
 
@@ -143,10 +209,15 @@ func GetNews(db *gorm.DB) gin.HandlerFunc {
 
 		id := ctx.Param("id")
 		var news models.News
+		lang, err := responseLanguage(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
 		// 2. Find the first record that matches the ID.
-		// Will use GORM `First` method for that
-		if err := db.First(&news, id).Error; err != nil {
+		// Will use GORM `First` method for that, unpublished news are not found
+		if err := db.Scopes(publishedNews).First(&news, id).Error; err != nil {
 			// Handle the case where no record found.
 			if err == gorm.ErrRecordNotFound {
 				ctx.JSON(http.StatusNotFound, gin.H{"error": "News not found"})
@@ -157,7 +228,13 @@ func GetNews(db *gorm.DB) gin.HandlerFunc {
 			}
 			return
 		}
-		response := toNewsResponse(news)
+		if lang != "" {
+			newsLocalizer := newLocalizer(db, &news, []uint{news.ID}, lang)
+			ctx.JSON(http.StatusOK, toLocalizedNewsResponse(news, newsImages(db, news), newsLocalizer))
+			return
+		}
+		response := toNewsResponse(news, newsImages(db, news))
+		response.Translations = loadExtraTranslations(db, &news, []uint{news.ID})[news.ID]
 		ctx.JSON(http.StatusOK, response)
 	}
 }
@@ -170,6 +247,12 @@ type CreateNewsRequest struct {
 	PostedOn    time.Time `json:"posted_on" binding:"required"`
 	ImageLeft   string    `json:"image_left" binding:"required"`
 	ImageRight  string    `json:"image_right" binding:"required"`
+	// Publication status, published when omitted. Scheduled news need publish_at.
+	Status      string     `json:"status" binding:"omitempty,oneof=draft scheduled published archived"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	// Translations for languages without their own fields, e.g. {"de": {"title": "..."}}
+	Translations translation.Values `json:"translations"`
 }
 
 func CreateNews(db *gorm.DB) gin.HandlerFunc {
@@ -189,28 +272,28 @@ func CreateNews(db *gorm.DB) gin.HandlerFunc {
 			PostedOn:    request.PostedOn,
 			ImageLeft:   request.ImageLeft,
 			ImageRight:  request.ImageRight,
-			// Set translated fields to default language
-			TitlePL:       request.Title,
-			HeaderPL:      request.Header,
-			DescriptionPL: request.Description,
-			FeaturesPL:    request.Features,
-			TitleEN:       request.Title,
-			HeaderEN:      request.Header,
-			DescriptionEN: request.Description,
-			FeaturesEN:    request.Features,
-			TitleUK:       request.Title,
-			HeaderUK:      request.Header,
-			DescriptionUK: request.Description,
-			FeaturesUK:    request.Features,
+			Status:      models.NewsPublished,
 		}
+		if err := newsSchedule(&singleNews, request.Status, request.PublishAt, request.UnpublishAt); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Set translated fields to default language
+		translation.Apply(&singleNews, request.Translations)
+		translation.FillDefaults(&singleNews)
 		// 2. Create news record in the database.
 		if err := db.Create(&singleNews).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create News"})
 			return
 		}
+		if err := translation.Store(db, &singleNews, request.Translations); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save News translations"})
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &singleNews, nil)
 		// Return created record as a response
 		// A 201 Created status will return
-		response := toNewsResponse(singleNews)
+		response := toNewsResponse(singleNews, newsImages(db, singleNews))
 		ctx.JSON(http.StatusCreated, response)
 	}
 }
@@ -235,6 +318,12 @@ type UpdateNewsRequest struct {
 	HeaderUK      string    `json:"header_uk"`
 	DescriptionUK string    `json:"description_uk"`
 	FeaturesUK    string    `json:"features_uk"`
+	// Publication status, unchanged when omitted. Scheduled news need publish_at.
+	Status      string     `json:"status" binding:"omitempty,oneof=draft scheduled published archived"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	// Translations for languages without their own fields, e.g. {"de": {"title": "..."}}
+	Translations translation.Values `json:"translations"`
 }
 
 func UpdateNews(db *gorm.DB) gin.HandlerFunc {
@@ -253,6 +342,9 @@ func UpdateNews(db *gorm.DB) gin.HandlerFunc {
 			}
 			return
 		}
+		before := auditState(newsItem)
+		revision := takeRevision(db, &newsItem)
+		oldImages := []string{newsItem.ImageLeft, newsItem.ImageRight}
 		// Binding incoming JSON to a request struct.
 		var request UpdateNewsRequest
 		if err := ctx.ShouldBindJSON(&request); err != nil {
@@ -279,14 +371,27 @@ func UpdateNews(db *gorm.DB) gin.HandlerFunc {
 		newsItem.HeaderUK = request.HeaderUK
 		newsItem.DescriptionUK = request.DescriptionUK
 		newsItem.FeaturesUK = request.FeaturesUK
+		if err := newsSchedule(&newsItem, request.Status, request.PublishAt, request.UnpublishAt); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		translation.Apply(&newsItem, request.Translations)
 
 		// Saving updated news to database
 		if err := db.Save(&newsItem).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update News"})
 			return
 		}
+		if err := translation.Store(db, &newsItem, request.Translations); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save News translations"})
+			return
+		}
+		saveRevision(ctx, db, revision, &newsItem)
+		recordAudit(ctx, db, models.AuditUpdate, &newsItem, before)
+		// Replaced images are kept while a revision shows them, see media.Release
+		releaseImages(db, oldImages...)
 		// Return updated response
-		response := toNewsResponse(newsItem)
+		response := toNewsResponse(newsItem, newsImages(db, newsItem))
 		ctx.JSON(http.StatusOK, response)
 	}
 }
@@ -295,18 +400,25 @@ func DeleteNews(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// 1. Get the ID from URL
 		id := ctx.Param("id")
-		// 2. Find the news in the database
-		result := db.Delete(&models.News{}, id)
-		// 3. Handle DB errors
-		if result.Error != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete News"})
+		// 2. Find the news, its fields are kept in the audit log
+		var news models.News
+		if err := db.First(&news, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "News not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database Error"})
+			}
 			return
 		}
-		// 4. Check if record was deleted
-		if result.RowsAffected == 0 {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "News not found"})
+		before := auditState(news)
+		// 3. Delete the news
+		if err := db.Delete(&news).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete News"})
 			return
 		}
+		recordAudit(ctx, db, models.AuditDelete, &news, before)
+		// 4. Send success response.
+		// The standard response for a successful DELETE is 204 No Content.
 		ctx.Status(http.StatusNoContent)
 	}
 }
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
@@ -16,30 +17,119 @@ type PriceResponse struct {
     ItemNameUK string  `json:"position_uk"`
     Price      float32 `json:"price,string"` // The ",string" option formats the number as a string
     Category   string  `json:"category"`
+    Program    *uint   `json:"program"`
+    Variant    string  `json:"variant"`
+    Sessions   int     `json:"sessions"`
+    Area       string  `json:"area"`
+    // Languages without their own fields, e.g. {"de": {"item_name": "..."}}
+    Translations translation.Values `json:"translations,omitempty"`
 }
 
+// toPriceResponse converts a models.Price to a PriceResponse.
+func toPriceResponse(price models.Price) PriceResponse {
+	return PriceResponse{
+		ID:         price.ID,
+		ItemName:   price.ItemName,
+		Price:      price.Price,
+		Category:   price.Category,
+		ItemNamePL: price.ItemNamePL,
+		ItemNameEN: price.ItemNameEN,
+		ItemNameUK: price.ItemNameUK,
+		Program:    price.ProgramID,
+		Variant:    price.Variant,
+		Sessions:   price.Sessions,
+		Area:       price.Area,
+	}
+}
+
+// LocalizedPriceResponse is the compact view of a price in a single language,
+// returned for ?lang=<code> or a matching Accept-Language header.
+type LocalizedPriceResponse struct {
+	ID       uint    `json:"pk"`
+	Language string  `json:"language"`
+	ItemName string  `json:"position"`
+	Price    float32 `json:"price,string"`
+	Category string  `json:"category"`
+	Program  *uint   `json:"program"`
+	Variant  string  `json:"variant"`
+	Sessions int     `json:"sessions"`
+	Area     string  `json:"area"`
+}
+
+// toLocalizedPriceResponse converts a models.Price to a LocalizedPriceResponse.
+func toLocalizedPriceResponse(price models.Price, prices localizer) LocalizedPriceResponse {
+	return LocalizedPriceResponse{
+		ID:       price.ID,
+		Language: prices.lang,
+		ItemName: prices.texts(&price)["item_name"],
+		Price:    price.Price,
+		Category: price.Category,
+		Program:  price.ProgramID,
+		Variant:  price.Variant,
+		Sessions: price.Sessions,
+		Area:     price.Area,
+	}
+}
+
+// priceListOptions are the filters and orderings supported by ListPrices.
+var priceListOptions = listOptions{
+	searchColumns: []string{"item_name", "item_name_pl", "item_name_en", "item_name_uk", "area"},
+	orderings: map[string]string{
+		"id":         "id",
+		"price":      "price",
+		"item_name":  "item_name",
+		"category":   "category",
+		"sessions":   "sessions",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	category:    true,
+	priceColumn: "price",
+}
+
+// ListPrices is the handler for fetching a page of prices.
 func ListPrices(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var prices []models.Price
-		// 1. Fetching all prices from the database.
-		if err := db.Find(&prices).Error; err != nil {
+		lang, err := responseLanguage(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		list, err := parseListQuery(ctx, priceListOptions)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// 1. Counting the matching prices and fetching the requested page.
+		count, err := list.count(db, &models.Price{})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count Prices"})
+			return
+		}
+		if err := db.Scopes(list.paginate).Find(&prices).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch Prices"})
 			return
 		}
 		// 2. Mapping the database models to our API responce structs.
-		var responces []PriceResponse
+		// A single language gets the compact view
+		if lang != "" {
+			pricesLocalizer := newLocalizer(db, &models.Price{}, priceIDs(prices), lang)
+			localized := make([]LocalizedPriceResponse, 0, len(prices))
+			for _, price := range prices {
+				localized = append(localized, toLocalizedPriceResponse(price, pricesLocalizer))
+			}
+			ctx.JSON(http.StatusOK, list.response(count, localized))
+			return
+		}
+		responces := make([]PriceResponse, 0, len(prices))
+		extra := loadExtraTranslations(db, &models.Price{}, priceIDs(prices))
 		for _, price := range prices {
-			responces = append(responces, PriceResponse{
-				ID:         price.ID,
-				ItemName:   price.ItemName,
-				Price:      price.Price,
-				Category:   price.Category,
-				ItemNamePL: price.ItemNamePL,
-				ItemNameEN: price.ItemNameEN,
-				ItemNameUK: price.ItemNameUK,
-			})
-		}
-		ctx.JSON(http.StatusOK, responces)
+			response := toPriceResponse(price)
+			response.Translations = extra[price.ID]
+			responces = append(responces, response)
+		}
+		ctx.JSON(http.StatusOK, list.response(count, responces))
 	}
 }
 
@@ -49,6 +139,11 @@ func GetPrice(db *gorm.DB) gin.HandlerFunc {
 
 		id := ctx.Param("id")
 		var price models.Price
+		lang, err := responseLanguage(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
 		// 2. Find first record matching ID.
 		// Will use GORM `First` method for that
@@ -63,15 +158,13 @@ func GetPrice(db *gorm.DB) gin.HandlerFunc {
 			}
 			return
 		}
-		response := PriceResponse{
-			ID:         price.ID,
-			ItemName:   price.ItemName,
-			Price:      price.Price,
-			Category:   price.Category,
-			ItemNamePL: price.ItemNamePL,
-			ItemNameEN: price.ItemNameEN,
-			ItemNameUK: price.ItemNameUK,
+		if lang != "" {
+			pricesLocalizer := newLocalizer(db, &price, []uint{price.ID}, lang)
+			ctx.JSON(http.StatusOK, toLocalizedPriceResponse(price, pricesLocalizer))
+			return
 		}
+		response := toPriceResponse(price)
+		response.Translations = loadExtraTranslations(db, &price, []uint{price.ID})[price.ID]
 		// Sending the responce
 		ctx.JSON(http.StatusOK, response)
 	}
@@ -83,6 +176,12 @@ type CreatePriceRequest struct {
 	ItemName string  `json:"item_name" binding:"required"`
 	Price    float32 `json:"price,string" binding:"required"`
 	Category string  `json:"category" binding:"required,len=2"`
+	Program  *uint   `json:"program"`
+	Variant  string  `json:"variant" binding:"omitempty,oneof=single package area"`
+	Sessions int     `json:"sessions" binding:"omitempty,min=1"`
+	Area     string  `json:"area" binding:"max=50"`
+	// Translations for languages without their own fields, e.g. {"de": {"item_name": "..."}}
+	Translations translation.Values `json:"translations"`
 }
 
 func CreatePrice(db *gorm.DB) gin.HandlerFunc {
@@ -93,21 +192,37 @@ func CreatePrice(db *gorm.DB) gin.HandlerFunc {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err := checkCategory(db, &request.Category); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := checkPriceProgram(db, request.Program); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		// Create price instance
 		price := models.Price{
-			ItemName: request.ItemName,
-			Price:    request.Price,
-			Category: request.Category,
-			// Set translated fields to default language
-			ItemNamePL: request.ItemName,
-			ItemNameEN: request.ItemName,
-			ItemNameUK: request.ItemName,
+			ItemName:  request.ItemName,
+			Price:     request.Price,
+			Category:  request.Category,
+			ProgramID: request.Program,
+			Variant:   request.Variant,
+			Sessions:  request.Sessions,
+			Area:      request.Area,
 		}
+		// Set translated fields to default language
+		translation.Apply(&price, request.Translations)
+		translation.FillDefaults(&price)
 		// 2. Create price record in the database.
 		if err := db.Create(&price).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create Price"})
 			return
 		}
+		if err := translation.Store(db, &price, request.Translations); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save Price translations"})
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &price, nil)
 		// Return created record as a response
 		// A 201 Created status will return
 		ctx.JSON(http.StatusCreated, price)
@@ -121,6 +236,12 @@ type UpdatePriceRequest struct {
 	ItemNamePL string  `json:"item_name_pl"`
 	ItemNameEN string  `json:"item_name_en"`
 	ItemNameUK string  `json:"item_name_uk"`
+	Program    *uint   `json:"program"`
+	Variant    string  `json:"variant" binding:"omitempty,oneof=single package area"`
+	Sessions   int     `json:"sessions" binding:"omitempty,min=1"`
+	Area       string  `json:"area" binding:"max=50"`
+	// Translations for languages without their own fields, e.g. {"de": {"item_name": "..."}}
+	Translations translation.Values `json:"translations"`
 }
 
 func UpdatePrice(db *gorm.DB) gin.HandlerFunc {
@@ -140,12 +261,22 @@ func UpdatePrice(db *gorm.DB) gin.HandlerFunc {
 			}
 			return
 		}
+		before := auditState(price)
+		revision := takeRevision(db, &price)
 		// 3. Bind the incoming JSON to a request struct.
 		var request UpdatePriceRequest
 		if err := ctx.ShouldBindJSON(&request); err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err := checkCategory(db, &request.Category); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := checkPriceProgram(db, request.Program); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		// 4.  Update the fields of the price model with the new data.
 		price.ItemName = request.ItemName
 		price.Price = request.Price
@@ -153,11 +284,26 @@ func UpdatePrice(db *gorm.DB) gin.HandlerFunc {
 		price.ItemNamePL = request.ItemNamePL
 		price.ItemNameEN = request.ItemNameEN
 		price.ItemNameUK = request.ItemNameUK
+		price.ProgramID = request.Program
+		price.Area = request.Area
+		if request.Variant != "" {
+			price.Variant = request.Variant
+		}
+		if request.Sessions != 0 {
+			price.Sessions = request.Sessions
+		}
+		translation.Apply(&price, request.Translations)
 		// 5. Save the updated price in the database.
 		if err := db.Save(&price).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update Price"})
 			return
 		}
+		if err := translation.Store(db, &price, request.Translations); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save Price translations"})
+			return
+		}
+		saveRevision(ctx, db, revision, &price)
+		recordAudit(ctx, db, models.AuditUpdate, &price, before)
 		// Return updated response
 		ctx.JSON(http.StatusOK, price)
 	}
@@ -167,20 +313,49 @@ func DeletePrice(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// 1. Get the ID from URL
 		id := ctx.Param("id")
-		// 2. Find the price in the database
-		result := db.Delete(&models.Price{}, id)
-		// 3. Handle DB errors
-		if result.Error != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete Price"})
+		// 2. Find the price, its fields are kept in the audit log
+		var price models.Price
+		if err := db.First(&price, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Price not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database Error"})
+			}
 			return
 		}
-		// 4. Check if record was deleted
-		if result.RowsAffected == 0 {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Price not found"})
+		before := auditState(price)
+		// 3. Delete the price
+		if err := db.Delete(&price).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete Price"})
 			return
 		}
-		// 5. Send success response.
+		recordAudit(ctx, db, models.AuditDelete, &price, before)
+		// 4. Send success response.
 		// The standard response for a successful DELETE is 204 No Content.
 		ctx.Status(http.StatusNoContent)
 	}
 }
+
+// checkPriceProgram makes sure the program a price is linked to exists.
+func checkPriceProgram(db *gorm.DB, programID *uint) error {
+	if programID == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.Program{}).Where("id = ?", *programID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errUnknownProgram
+	}
+	return nil
+}
+
+// priceIDs returns the IDs of the given prices.
+func priceIDs(prices []models.Price) []uint {
+	ids := make([]uint, 0, len(prices))
+	for _, price := range prices {
+		ids = append(ids, price.ID)
+	}
+	return ids
+}
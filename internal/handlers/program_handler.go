@@ -2,9 +2,11 @@ package handler
 
 import (
 	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
+	"strings"
 )
 
 // ProgramResponse defines the structure of the JSON response for a program.
@@ -24,40 +26,177 @@ type ProgramResponse struct {
 	ResultsPL     string `json:"results_pl"`
 	ResultsEN     string `json:"results_en"`
 	Category      string `json:"category"`
+	Duration      int    `json:"duration"`
+	// Nested prices are only included when requested with ?include=prices
+	Prices *[]PriceResponse `json:"prices,omitempty"`
+	// Languages without their own fields, e.g. {"de": {"title": "..."}}
+	Translations translation.Values `json:"translations,omitempty"`
 }
 
-// ListPrograms is the handler for fetching all programs.
+// toProgramResponse converts a models.Program to a ProgramResponse.
+// When withPrices is set, the program prices have to be preloaded.
+func toProgramResponse(program models.Program, withPrices bool) ProgramResponse {
+	response := ProgramResponse{
+		ID:            program.ID,
+		Title:         program.Title,
+		TitleUK:       program.TitleUK,
+		TitlePL:       program.TitlePL,
+		TitleEN:       program.TitleEN,
+		Description:   program.Description,
+		DescriptionUK: program.DescriptionUK,
+		DescriptionPL: program.DescriptionPL,
+		DescriptionEN: program.DescriptionEN,
+		Results:       program.Results,
+		ResultsUK:     program.ResultsUK,
+		ResultsPL:     program.ResultsPL,
+		ResultsEN:     program.ResultsEN,
+		Category:      program.Category,
+		Duration:      program.Duration,
+	}
+	if withPrices {
+		prices := make([]PriceResponse, 0, len(program.Prices))
+		for _, price := range program.Prices {
+			prices = append(prices, toPriceResponse(price))
+		}
+		response.Prices = &prices
+	}
+	return response
+}
+
+// LocalizedProgramResponse is the compact view of a program in a single language,
+// returned for ?lang=<code> or a matching Accept-Language header.
+type LocalizedProgramResponse struct {
+	ID          uint                      `json:"pk"`
+	Language    string                    `json:"language"`
+	Title       string                    `json:"title"`
+	Description string                    `json:"description"`
+	Results     string                    `json:"results"`
+	Category    string                    `json:"category"`
+	Duration    int                       `json:"duration"`
+	Prices      *[]LocalizedPriceResponse `json:"prices,omitempty"`
+}
+
+// toLocalizedProgramResponse converts a models.Program to a LocalizedProgramResponse.
+// Prices are localized when a localizer for them is given, they have to be preloaded.
+func toLocalizedProgramResponse(program models.Program, programs localizer, prices *localizer) LocalizedProgramResponse {
+	texts := programs.texts(&program)
+	response := LocalizedProgramResponse{
+		ID:          program.ID,
+		Language:    programs.lang,
+		Title:       texts["title"],
+		Description: texts["description"],
+		Results:     texts["results"],
+		Category:    program.Category,
+		Duration:    program.Duration,
+	}
+	if prices != nil {
+		localized := make([]LocalizedPriceResponse, 0, len(program.Prices))
+		for _, price := range program.Prices {
+			localized = append(localized, toLocalizedPriceResponse(price, *prices))
+		}
+		response.Prices = &localized
+	}
+	return response
+}
+
+// programPricesLocalizer returns the localizer for the preloaded prices of programs,
+// or nil when prices are not included.
+func programPricesLocalizer(db *gorm.DB, programs []models.Program, lang string, withPrices bool) *localizer {
+	if !withPrices {
+		return nil
+	}
+	var prices []models.Price
+	for _, program := range programs {
+		prices = append(prices, program.Prices...)
+	}
+	pricesLocalizer := newLocalizer(db, &models.Price{}, priceIDs(prices), lang)
+	return &pricesLocalizer
+}
+
+// programQuery returns the base query for programs, preloading prices when requested.
+func programQuery(db *gorm.DB, withPrices bool) *gorm.DB {
+	if withPrices {
+		return db.Preload("Prices", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("sessions asc, price asc")
+		})
+	}
+	return db
+}
+
+// includes reports whether the ?include= query lists the given relation, e.g. ?include=prices
+func includes(ctx *gin.Context, relation string) bool {
+	for _, value := range strings.Split(ctx.Query("include"), ",") {
+		if strings.TrimSpace(value) == relation {
+			return true
+		}
+	}
+	return false
+}
+
+// programListOptions are the filters and orderings supported by ListPrograms.
+// ?min_price= and ?max_price= match programs having a price in the range.
+var programListOptions = listOptions{
+	searchColumns: []string{"title", "title_pl", "title_en", "title_uk", "description"},
+	orderings: map[string]string{
+		"id":         "id",
+		"title":      "title",
+		"category":   "category",
+		"duration":   "duration",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	category:       true,
+	priceColumn:    "prices.price",
+	priceCondition: "programs.id IN (SELECT program_id FROM prices WHERE prices.deleted_at IS NULL AND %s)",
+}
+
+// ListPrograms is the handler for fetching a page of programs.
 // It accepts the GORM database connection as an argument.
 func ListPrograms(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var programs []models.Program
-		// 1. Fetching all programs from the database.
-		if err := db.Find(&programs).Error; err != nil {
+		withPrices := includes(ctx, "prices")
+		lang, err := responseLanguage(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		list, err := parseListQuery(ctx, programListOptions)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// 1. Counting the matching programs and fetching the requested page.
+		count, err := list.count(db, &models.Program{})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count Programs"})
+			return
+		}
+		if err := programQuery(db, withPrices).Scopes(list.paginate).Find(&programs).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch Programs"})
 			return
 		}
 
 		// 2. Mapping the database models to our API responce structs.
-		var responses []ProgramResponse
+		// A single language gets the compact view
+		if lang != "" {
+			programsLocalizer := newLocalizer(db, &models.Program{}, programIDs(programs), lang)
+			pricesLocalizer := programPricesLocalizer(db, programs, lang, withPrices)
+			localized := make([]LocalizedProgramResponse, 0, len(programs))
+			for _, program := range programs {
+				localized = append(localized, toLocalizedProgramResponse(program, programsLocalizer, pricesLocalizer))
+			}
+			ctx.JSON(http.StatusOK, list.response(count, localized))
+			return
+		}
+		extra := loadExtraTranslations(db, &models.Program{}, programIDs(programs))
+		responses := make([]ProgramResponse, 0, len(programs))
 		for _, program := range programs {
-			responses = append(responses, ProgramResponse{
-				ID:            program.ID,
-				Title:         program.Title,
-				TitleUK:       program.TitleUK,
-				TitlePL:       program.TitlePL,
-				TitleEN:       program.TitleEN,
-				Description:   program.Description,
-				DescriptionUK: program.DescriptionUK,
-				DescriptionPL: program.DescriptionPL,
-				DescriptionEN: program.DescriptionEN,
-				Results:       program.Results,
-				ResultsUK:     program.ResultsUK,
-				ResultsPL:     program.ResultsPL,
-				ResultsEN:     program.ResultsEN,
-				Category:      program.Category,
-			})
-		}
-		ctx.JSON(http.StatusOK, responses)
+			response := toProgramResponse(program, withPrices)
+			response.Translations = extra[program.ID]
+			responses = append(responses, response)
+		}
+		ctx.JSON(http.StatusOK, list.response(count, responses))
 	}
 }
 
@@ -68,10 +207,16 @@ func GetProgram(db *gorm.DB) gin.HandlerFunc {
 
 		id := ctx.Param("id")
 		var program models.Program
+		withPrices := includes(ctx, "prices")
+		lang, err := responseLanguage(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
 		// 2. Find the first record that matches the ID.
 		// Will use GORM `First` method for that
-		if err := db.First(&program, id).Error; err != nil {
+		if err := programQuery(db, withPrices).First(&program, id).Error; err != nil {
 			// Handle the case where no record found.
 			if err == gorm.ErrRecordNotFound {
 				ctx.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
@@ -82,22 +227,14 @@ func GetProgram(db *gorm.DB) gin.HandlerFunc {
 			}
 			return
 		}
-		response := ProgramResponse{
-			ID:            program.ID,
-			Title:         program.Title,
-			TitleUK:       program.TitleUK,
-			TitlePL:       program.TitlePL,
-			TitleEN:       program.TitleEN,
-			Description:   program.Description,
-			DescriptionUK: program.DescriptionUK,
-			DescriptionPL: program.DescriptionPL,
-			DescriptionEN: program.DescriptionEN,
-			Results:       program.Results,
-			ResultsUK:     program.ResultsUK,
-			ResultsPL:     program.ResultsPL,
-			ResultsEN:     program.ResultsEN,
-			Category:      program.Category,
+		if lang != "" {
+			programsLocalizer := newLocalizer(db, &program, []uint{program.ID}, lang)
+			pricesLocalizer := programPricesLocalizer(db, []models.Program{program}, lang, withPrices)
+			ctx.JSON(http.StatusOK, toLocalizedProgramResponse(program, programsLocalizer, pricesLocalizer))
+			return
 		}
+		response := toProgramResponse(program, withPrices)
+		response.Translations = loadExtraTranslations(db, &program, []uint{program.ID})[program.ID]
 		// Sending the responce
 		ctx.JSON(http.StatusOK, response)
 	}
@@ -110,6 +247,9 @@ type CreateProgramRequest struct {
 	Description string `json:"description"`
 	Results     string `json:"results"`
 	Category    string `json:"category" binding:"required"`
+	Duration    int    `json:"duration" binding:"omitempty,min=5,max=480"`
+	// Translations for languages without their own fields, e.g. {"de": {"title": "..."}}
+	Translations translation.Values `json:"translations"`
 }
 
 // CreateProgram is the handler for creating a new program.
@@ -123,27 +263,30 @@ func CreateProgram(db *gorm.DB) gin.HandlerFunc {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err := checkCategory(db, &request.Category); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		program := models.Program{
 			Title:       request.Title,
 			Description: request.Description,
 			Results:     request.Results,
 			Category:    request.Category,
-			// Set translated fields to default language
-			TitlePL:       request.Title,
-			TitleEN:       request.Title,
-			TitleUK:       request.Title,
-			DescriptionPL: request.Description,
-			DescriptionEN: request.Description,
-			DescriptionUK: request.Description,
-			ResultsPL:     request.Results,
-			ResultsEN:     request.Results,
-			ResultsUK:     request.Results,
+			Duration:    request.Duration,
 		}
+		// Set translated fields to default language
+		translation.Apply(&program, request.Translations)
+		translation.FillDefaults(&program)
 		// 2. Create the program in the database.
 		if err := db.Create(&program).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create program"})
 			return
 		}
+		if err := translation.Store(db, &program, request.Translations); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save program translations"})
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &program, nil)
 		// Return created record as a response
 		// A 201 Created status will return
 		ctx.JSON(http.StatusCreated, program)
@@ -158,6 +301,7 @@ type UpdateProgramRequest struct {
 	Description string `json:"description"`
 	Results     string `json:"results"`
 	Category    string `json:"category" binding:"required,len=2"`
+	Duration    int    `json:"duration" binding:"omitempty,min=5,max=480"`
 	// Will add translated fields to allow them to be updated
 	TitlePL       string `json:"title_pl"`
 	TitleEN       string `json:"title_en"`
@@ -168,6 +312,8 @@ type UpdateProgramRequest struct {
 	ResultsPL     string `json:"results_pl"`
 	ResultsEN     string `json:"results_en"`
 	ResultsUK     string `json:"results_uk"`
+	// Translations for languages without their own fields, e.g. {"de": {"title": "..."}}
+	Translations translation.Values `json:"translations"`
 }
 
 func UpdateProgram(db *gorm.DB) gin.HandlerFunc {
@@ -187,17 +333,26 @@ func UpdateProgram(db *gorm.DB) gin.HandlerFunc {
 			}
 			return
 		}
+		before := auditState(program)
+		revision := takeRevision(db, &program)
 		// 3. Bind the incoming JSON to a request struct.
 		var request UpdateProgramRequest
 		if err := ctx.ShouldBindJSON(&request); err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err := checkCategory(db, &request.Category); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		// 4.  Update the fields of the program model with the new data.
 		program.Title = request.Title
 		program.Description = request.Description
 		program.Results = request.Results
 		program.Category = request.Category
+		if request.Duration != 0 {
+			program.Duration = request.Duration
+		}
 		program.TitlePL = request.TitlePL
 		program.TitleEN = request.TitleEN
 		program.TitleUK = request.TitleUK
@@ -207,12 +362,19 @@ func UpdateProgram(db *gorm.DB) gin.HandlerFunc {
 		program.ResultsPL = request.ResultsPL
 		program.ResultsEN = request.ResultsEN
 		program.ResultsUK = request.ResultsUK
+		translation.Apply(&program, request.Translations)
 
 		//5. Save the updated record to the database.
 		if err := db.Save(&program).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update program"})
 			return
 		}
+		if err := translation.Store(db, &program, request.Translations); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save program translations"})
+			return
+		}
+		saveRevision(ctx, db, revision, &program)
+		recordAudit(ctx, db, models.AuditUpdate, &program, before)
 
 		// Return updated response
 		ctx.JSON(http.StatusOK, program)
@@ -225,22 +387,34 @@ func DeleteProgram(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// 1. Get the ID from URL
 		id := ctx.Param("id")
-		// 2. Use GORM to delete the program using ID.
-		// We execute the delete and check the number of rows affected.
-		result := db.Delete(&models.Program{}, id)
-
-		// 3. Handle DB errors
-		if result.Error != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete program"})
+		// 2. Find the program, its fields are kept in the audit log
+		var program models.Program
+		if err := db.First(&program, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database Error"})
+			}
 			return
 		}
-		// 4. Check if record was deleted
-		if result.RowsAffected == 0 {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		before := auditState(program)
+		// 3. Delete the program
+		if err := db.Delete(&program).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete program"})
 			return
 		}
-		// 5. Send success response.
+		recordAudit(ctx, db, models.AuditDelete, &program, before)
+		// 4. Send success response.
 		// The standard response for a successful DELETE is 204 No Content.
 		ctx.Status(http.StatusNoContent)
 	}
 }
+
+// programIDs returns the IDs of the given programs.
+func programIDs(programs []models.Program) []uint {
+	ids := make([]uint, 0, len(programs))
+	for _, program := range programs {
+		ids = append(ids, program.ID)
+	}
+	return ids
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// revisionHistoryLimit is the number of revisions listed in the edit form.
+const revisionHistoryLimit = 20
+
+// RevisionResource describes a content type with a revision history in the admin panel.
+type RevisionResource struct {
+	// Path is the admin path of the resource, e.g. /admin/programs
+	Path string
+	// Row is the table row template, rendered after a restore, e.g. program-row.html
+	Row string
+}
+
+// rowID returns the HTML id of the table row of a record, e.g. program-row-5.
+func (r RevisionResource) rowID(id uint) string {
+	return fmt.Sprintf("%s-%d", strings.TrimSuffix(r.Row, ".html"), id)
+}
+
+// revisionLine is a field of a revision next to its current value.
+type revisionLine struct {
+	Field    string
+	Language string
+	Revision string
+	Current  string
+	Changed  bool
+}
+
+// revisionData returns the fields of a record kept in a revision.
+// The ID, timestamps and related records are left out, so restoring
+// a revision only brings back the content of the record itself.
+func revisionData(db *gorm.DB, entity any) map[string]any {
+	encoded, err := json.Marshal(entity)
+	if err != nil {
+		return nil
+	}
+	var data map[string]any
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil
+	}
+	for field := range auditIgnored {
+		delete(data, field)
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(entity); err == nil {
+		for relation := range stmt.Schema.Relationships.Relations {
+			delete(data, relation)
+		}
+	}
+	return data
+}
+
+// takeRevision returns the current version of a record as an unsaved revision.
+// Take it before changing a record and pass it to saveRevision afterwards.
+func takeRevision(db *gorm.DB, entity any) models.Revision {
+	id := translation.EntityID(entity)
+	data, _ := json.Marshal(revisionData(db, entity))
+	extra, _ := json.Marshal(loadExtraTranslations(db, entity, []uint{id})[id])
+	return models.Revision{
+		EntityType:   translation.EntityType(entity),
+		EntityID:     id,
+		Data:         string(data),
+		Translations: string(extra),
+	}
+}
+
+// saveRevision keeps the version of a record taken before it was changed.
+// Nothing is saved when the record did not change. A failure is only logged,
+// as the change itself has already been saved.
+func saveRevision(ctx *gin.Context, db *gorm.DB, revision models.Revision, entity any) {
+	current := takeRevision(db, entity)
+	if current.Data == revision.Data && current.Translations == revision.Translations {
+		return
+	}
+	revision.UserID, revision.UserName, _ = changedBy(ctx, db)
+	if err := db.Create(&revision).Error; err != nil {
+		log.Printf("Failed to save revision of %s %d: %s", revision.EntityType, revision.EntityID, err)
+	}
+}
+
+// revisionTranslations decodes the extra language texts of a revision.
+func revisionTranslations(revision models.Revision) translation.Values {
+	values := translation.Values{}
+	if err := json.Unmarshal([]byte(revision.Translations), &values); err != nil || values == nil {
+		return translation.Values{}
+	}
+	return values
+}
+
+// revisionDiff puts every field of a revision next to the current value of the record.
+// Translatable fields get a line per enabled language.
+func revisionDiff[T any](db *gorm.DB, revision models.Revision, current *T) []revisionLine {
+	var old T
+	if err := json.Unmarshal([]byte(revision.Data), &old); err != nil {
+		log.Printf("Failed to decode revision %d: %s", revision.ID, err)
+	}
+	oldValues := translation.Extract(&old)
+	oldValues.Merge(revisionTranslations(revision))
+	id := translation.EntityID(current)
+	currentValues := translation.Extract(current)
+	currentValues.Merge(loadExtraTranslations(db, current, []uint{id})[id])
+
+	var lines []revisionLine
+	for _, field := range translation.Fields(current) {
+		for _, lang := range translation.Languages() {
+			before, after := oldValues.Get(lang, field), currentValues.Get(lang, field)
+			lines = append(lines, revisionLine{
+				Field:    field,
+				Language: strings.ToUpper(lang),
+				Revision: before,
+				Current:  after,
+				Changed:  before != after,
+			})
+		}
+	}
+
+	// Fields without translations, in a stable order
+	skip := map[string]bool{}
+	for _, column := range translation.Columns(current) {
+		skip[column] = true
+	}
+	var oldData map[string]any
+	json.Unmarshal([]byte(revision.Data), &oldData)
+	currentData := revisionData(db, current)
+	var fields []string
+	for field := range currentData {
+		if !skip[field] {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		before, after := models.DisplayValue(oldData[field]), models.DisplayValue(currentData[field])
+		lines = append(lines, revisionLine{Field: field, Revision: before, Current: after, Changed: before != after})
+	}
+	return lines
+}
+
+// findRevision loads a revision of a record, making sure it belongs to it.
+func findRevision(db *gorm.DB, entity any, revisionID string) (models.Revision, error) {
+	var revision models.Revision
+	err := db.Where("entity_type = ? AND entity_id = ?", translation.EntityType(entity), translation.EntityID(entity)).
+		First(&revision, revisionID).Error
+	return revision, err
+}
+
+// AdminListRevisions renders the history of a record for its edit form.
+func AdminListRevisions[T any](db *gorm.DB, resource RevisionResource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		var record T
+		if err := db.First(&record, id).Error; err != nil {
+			log.Printf("Failed to find %s with ID %s: %s", translation.EntityType(&record), id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		var revisions []models.Revision
+		if err := db.Where("entity_type = ? AND entity_id = ?", translation.EntityType(&record), translation.EntityID(&record)).
+			Order("id desc").Limit(revisionHistoryLimit).Find(&revisions).Error; err != nil {
+			log.Printf("Failed to load revisions: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		ctx.HTML(http.StatusOK, "revisions.html", gin.H{
+			"Path":     resource.Path,
+			"EntityID": translation.EntityID(&record),
+			"Items":    revisions,
+		})
+	}
+}
+
+// AdminShowRevision renders a revision side by side with the current version of the record.
+func AdminShowRevision[T any](db *gorm.DB, resource RevisionResource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		var record T
+		if err := db.First(&record, id).Error; err != nil {
+			log.Printf("Failed to find %s with ID %s: %s", translation.EntityType(&record), id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		revision, err := findRevision(db, &record, ctx.Param("revision"))
+		if err != nil {
+			log.Printf("Failed to find revision %s: %s", ctx.Param("revision"), err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		ctx.HTML(http.StatusOK, "revision-diff.html", gin.H{
+			"Path":     resource.Path,
+			"EntityID": revision.EntityID,
+			"RowID":    resource.rowID(revision.EntityID),
+			"Revision": revision,
+			"Lines":    revisionDiff(db, revision, &record),
+		})
+	}
+}
+
+// AdminRestoreRevision brings a record back to a revision. The replaced version
+// is kept as a new revision, so a restore can be undone the same way.
+func AdminRestoreRevision[T any](db *gorm.DB, resource RevisionResource) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// 1. Find the record and the revision
+		id := ctx.Param("id")
+		var record T
+		if err := db.First(&record, id).Error; err != nil {
+			log.Printf("Failed to find %s with ID %s: %s", translation.EntityType(&record), id, err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		revision, err := findRevision(db, &record, ctx.Param("revision"))
+		if err != nil {
+			log.Printf("Failed to find revision %s: %s", ctx.Param("revision"), err)
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		current := takeRevision(db, &record)
+		before := auditState(&record)
+
+		// 2. Copy the revision fields onto the record
+		if err := json.Unmarshal([]byte(revision.Data), &record); err != nil {
+			log.Printf("Failed to decode revision %d: %s", revision.ID, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		// Languages missing from the revision are cleared
+		stored := revisionTranslations(revision)
+		values := translation.Values{}
+		for _, lang := range translation.ExtraLanguages(&record) {
+			for _, field := range translation.Fields(&record) {
+				values.Set(lang, field, stored.Get(lang, field))
+			}
+		}
+
+		// 3. Save the restored record and its translations
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit(clause.Associations).Save(&record).Error; err != nil {
+				return err
+			}
+			return translation.Store(tx, &record, values)
+		})
+		if err != nil {
+			log.Printf("Failed to restore revision %d: %s", revision.ID, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		saveRevision(ctx, db, current, &record)
+		recordAudit(ctx, db, models.AuditUpdate, &record, before)
+
+		// 4. Return the updated row, with its related records
+		if err := db.Preload(clause.Associations).First(&record, id).Error; err != nil {
+			log.Printf("Failed to reload %s with ID %s: %s", translation.EntityType(&record), id, err)
+		}
+		session := sessions.Default(ctx)
+		ctx.HTML(http.StatusOK, resource.Row, gin.H{
+			"Item":     record,
+			"UserRole": session.Get("userRole"),
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package handler
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/database"
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Limits of the search endpoint.
+const (
+	searchDefaultResults = 20
+	searchMaxResults     = 50
+	// Terms beyond this are ignored
+	searchMaxTerms = 10
+	// Length of the highlighted snippet, in characters
+	searchSnippetLength = 160
+)
+
+// SearchHit is a single result of the site search.
+// Highlight is an HTML-escaped snippet with the matched terms wrapped in <mark>.
+type SearchHit struct {
+	Type      string  `json:"type"`
+	ID        uint    `json:"pk"`
+	Title     string  `json:"title"`
+	Highlight string  `json:"highlight"`
+	Score     float64 `json:"score"`
+}
+
+// SearchResponse defines the structure of the JSON response of the search endpoint.
+type SearchResponse struct {
+	Query    string      `json:"query"`
+	Language string      `json:"language"`
+	Count    int         `json:"count"`
+	Results  []SearchHit `json:"results"`
+}
+
+// searchScopes limit the records of a type to those visitors may see.
+var searchScopes = map[string]func(*gorm.DB) *gorm.DB{
+	"news": publishedNews,
+}
+
+// searchRequest is the parsed query of a search.
+type searchRequest struct {
+	terms   []string
+	pattern *regexp.Regexp
+	lang    string
+	limit   int
+}
+
+// Search is the handler for searching programs, prices and news together,
+// e.g. /api/v1/search?q=laser&type=program,news&limit=10
+func Search(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// 1. Parse the search terms, the language and the limit
+		query := strings.TrimSpace(ctx.Query("q"))
+		terms := searchTerms(query)
+		if len(terms) == 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+			return
+		}
+		lang, err := responseLanguage(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if lang == "" {
+			lang = translation.Default()
+		}
+		request := searchRequest{terms: terms, pattern: termsPattern(terms), lang: lang, limit: searchDefaultResults}
+		if limit, err := strconv.Atoi(ctx.Query("limit")); err == nil && limit > 0 {
+			request.limit = min(limit, searchMaxResults)
+		}
+		types, err := searchTypes(ctx.Query("type"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// 2. Search every requested type
+		hits := []SearchHit{}
+		for _, kind := range types {
+			var found []SearchHit
+			var err error
+			switch kind {
+			case "program":
+				found, err = searchTable[models.Program](db, kind, "title", request)
+			case "price":
+				found, err = searchTable[models.Price](db, kind, "item_name", request)
+			case "news":
+				found, err = searchTable[models.News](db, kind, "title", request)
+			}
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search " + kind})
+				return
+			}
+			hits = append(hits, found...)
+		}
+
+		// 3. Best hits first
+		sort.SliceStable(hits, func(i, j int) bool {
+			return hits[i].Score > hits[j].Score
+		})
+		if len(hits) > request.limit {
+			hits = hits[:request.limit]
+		}
+		ctx.JSON(http.StatusOK, SearchResponse{
+			Query:    query,
+			Language: lang,
+			Count:    len(hits),
+			Results:  hits,
+		})
+	}
+}
+
+// searchTypes parses the ?type= filter. All types are searched when it is empty.
+func searchTypes(value string) ([]string, error) {
+	all := []string{"program", "price", "news"}
+	if strings.TrimSpace(value) == "" {
+		return all, nil
+	}
+	var types []string
+	for _, kind := range strings.Split(value, ",") {
+		kind = strings.TrimSpace(kind)
+		if _, ok := database.SearchIndexes[kind]; !ok {
+			return nil, fmt.Errorf("unknown type %q, use %s", kind, strings.Join(all, ", "))
+		}
+		types = append(types, kind)
+	}
+	return types, nil
+}
+
+// searchTerms splits a query into lowercase terms, dropping the characters
+// that are operators of the MySQL boolean full-text mode and one-letter words.
+func searchTerms(query string) []string {
+	clean := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`+-<>()~*"@`, r) {
+			return ' '
+		}
+		return r
+	}, strings.ToLower(query))
+	var terms []string
+	seen := map[string]bool{}
+	for _, term := range strings.Fields(clean) {
+		// Single characters would match almost everything
+		if seen[term] || utf8.RuneCountInString(term) < 2 || len(terms) == searchMaxTerms {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// termsPattern returns a case-insensitive pattern matching any of the terms.
+func termsPattern(terms []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// searchTable finds the records of one type matching the terms.
+// MySQL ranks them with the FULLTEXT index, other databases use LIKE and a simple
+// term count. Languages stored in the translations table are matched with LIKE.
+func searchTable[T any](db *gorm.DB, kind, titleField string, request searchRequest) ([]SearchHit, error) {
+	index := database.SearchIndexes[kind]
+	visible, ok := searchScopes[kind]
+	if !ok {
+		visible = func(tx *gorm.DB) *gorm.DB { return tx }
+	}
+	scores := map[uint]float64{}
+	var ids []uint
+
+	if database.SupportsFullText(db) {
+		var matches []struct {
+			ID    uint
+			Score float64
+		}
+		match := fmt.Sprintf("MATCH(%s) AGAINST(? IN BOOLEAN MODE)", strings.Join(index.Columns, ", "))
+		against := booleanQuery(request.terms)
+		err := db.Model(new(T)).Scopes(visible).Select("id, "+match+" AS score", against).
+			Where(match, against).Order("score desc").Limit(request.limit).Scan(&matches).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			scores[m.ID] = m.Score
+			ids = append(ids, m.ID)
+		}
+	} else {
+		var conditions []string
+		var args []any
+		for _, column := range index.Columns {
+			for _, term := range request.terms {
+				conditions = append(conditions, database.Like(db, column))
+				args = append(args, "%"+database.EscapeLike(term)+"%")
+			}
+		}
+		// Ranked after loading, so scan more rows than returned
+		if err := db.Model(new(T)).Scopes(visible).Where(strings.Join(conditions, " OR "), args...).
+			Limit(searchMaxResults).Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Languages without translation columns
+	if len(translation.ExtraLanguages(new(T))) > 0 {
+		var translated []uint
+		var conditions []string
+		var args []any
+		for _, term := range request.terms {
+			conditions = append(conditions, database.Like(db, "value"))
+			args = append(args, "%"+database.EscapeLike(term)+"%")
+		}
+		err := db.Model(&models.Translation{}).Distinct("entity_id").
+			Where("entity_type = ?", translation.EntityType(new(T))).
+			Where(strings.Join(conditions, " OR "), args...).
+			Limit(searchMaxResults).Pluck("entity_id", &translated).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range translated {
+			if _, ok := scores[id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// Load the records for titles and highlights, which also drops
+	// the hidden records matched through their translations
+	var rows []T
+	if err := db.Scopes(visible).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	extra := loadExtraTranslations(db, new(T), ids)
+	hits := make([]SearchHit, 0, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		id := translation.EntityID(row)
+		values := translation.Extract(row)
+		values.Merge(extra[id])
+		localized := values.Localized(request.lang)
+
+		score, ok := scores[id]
+		if !ok {
+			score = termScore(values, titleField, request.pattern)
+		}
+		hits = append(hits, SearchHit{
+			Type:      kind,
+			ID:        id,
+			Title:     localized[titleField],
+			Highlight: searchHighlight(translation.Fields(row), localized, values, request.pattern),
+			Score:     score,
+		})
+	}
+	return hits, nil
+}
+
+// booleanQuery turns the terms into a MySQL boolean mode query matching word prefixes.
+func booleanQuery(terms []string) string {
+	words := make([]string, 0, len(terms))
+	for _, term := range terms {
+		words = append(words, term+"*")
+	}
+	return strings.Join(words, " ")
+}
+
+// termScore ranks a record found without FULLTEXT by counting the matched terms.
+// Matches in the title weigh more than in the other fields.
+func termScore(values translation.Values, titleField string, pattern *regexp.Regexp) float64 {
+	var score float64
+	for _, fields := range values {
+		for field, value := range fields {
+			matches := float64(len(pattern.FindAllStringIndex(value, -1)))
+			if field == titleField {
+				matches *= 3
+			}
+			score += matches
+		}
+	}
+	return score
+}
+
+// searchHighlight returns a snippet of the first field matching the terms, preferring
+// the requested language. It falls back to the start of the first non-empty field.
+func searchHighlight(fields []string, localized map[string]string, values translation.Values, pattern *regexp.Regexp) string {
+	for _, field := range fields {
+		if pattern.MatchString(localized[field]) {
+			return highlightSnippet(localized[field], pattern)
+		}
+	}
+	for _, field := range fields {
+		for _, lang := range translation.Languages() {
+			if value := values.Get(lang, field); pattern.MatchString(value) {
+				return highlightSnippet(value, pattern)
+			}
+		}
+	}
+	for _, field := range fields {
+		if localized[field] != "" {
+			return highlightSnippet(localized[field], pattern)
+		}
+	}
+	return ""
+}
+
+// highlightSnippet cuts about searchSnippetLength characters around the first match
+// and wraps every match in <mark>. The rest of the text is HTML-escaped.
+func highlightSnippet(text string, pattern *regexp.Regexp) string {
+	text = strings.Join(strings.Fields(text), " ")
+	start, end := 0, len(text)
+	if utf8.RuneCountInString(text) > searchSnippetLength {
+		first := 0
+		if loc := pattern.FindStringIndex(text); loc != nil {
+			first = loc[0]
+		}
+		// Start a third of the snippet before the match, on a character boundary
+		start = runeOffset(text, first, -searchSnippetLength/3)
+		end = runeOffset(text, start, searchSnippetLength)
+	}
+	snippet := text[start:end]
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(snippet, -1) {
+		b.WriteString(html.EscapeString(snippet[last:loc[0]]))
+		b.WriteString("<mark>" + html.EscapeString(snippet[loc[0]:loc[1]]) + "</mark>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(snippet[last:]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// runeOffset moves a byte offset of text by n characters, staying within the text.
+func runeOffset(text string, offset, n int) int {
+	for ; n < 0 && offset > 0; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:offset])
+		offset -= size
+	}
+	for ; n > 0 && offset < len(text); n-- {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return offset
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errUnknownProgram = errors.New("unknown program")
+
+// SpecialistResponse defines the structure of the JSON response for a specialist.
+// It follows the same Django-compatible shape as ProgramResponse.
+type SpecialistResponse struct {
+	ID             uint    `json:"pk"`
+	Name           string  `json:"name"`
+	Bio            string  `json:"bio"`
+	BioUK          string  `json:"bio_uk"`
+	BioPL          string  `json:"bio_pl"`
+	BioEN          string  `json:"bio_en"`
+	Qualifications string  `json:"qualifications"`
+	Photo          *string `json:"photo"`
+	Programs       []uint  `json:"programs"`
+}
+
+// toSpecialistResponse converts a models.Specialist to a SpecialistResponse.
+// Programs have to be preloaded to be included in the response.
+func toSpecialistResponse(specialist models.Specialist) SpecialistResponse {
+	var photo *string
+	if specialist.Photo != "" {
+		url := storage.URL(specialist.Photo)
+		photo = &url
+	}
+	programs := make([]uint, 0, len(specialist.Programs))
+	for _, program := range specialist.Programs {
+		programs = append(programs, program.ID)
+	}
+	return SpecialistResponse{
+		ID:             specialist.ID,
+		Name:           specialist.Name,
+		Bio:            specialist.Bio,
+		BioUK:          specialist.BioUK,
+		BioPL:          specialist.BioPL,
+		BioEN:          specialist.BioEN,
+		Qualifications: specialist.Qualifications,
+		Photo:          photo,
+		Programs:       programs,
+	}
+}
+
+// ListSpecialists is the handler for fetching all specialists.
+// Passing ?program=ID returns only the specialists performing that program.
+func ListSpecialists(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var specialists []models.Specialist
+		query := db.Preload("Programs").Order("id asc")
+		if program := ctx.Query("program"); program != "" {
+			query = query.Where("id IN (?)", db.Table("specialist_programs").Select("specialist_id").Where("program_id = ?", program))
+		}
+		// 1. Fetching specialists from the database.
+		if err := query.Find(&specialists).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch Specialists"})
+			return
+		}
+		// 2. Mapping the database models to our API responce structs.
+		responses := make([]SpecialistResponse, 0, len(specialists))
+		for _, specialist := range specialists {
+			responses = append(responses, toSpecialistResponse(specialist))
+		}
+		ctx.JSON(http.StatusOK, responses)
+	}
+}
+
+// GetSpecialist is the handler for fetching a single specialist by its ID.
+func GetSpecialist(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// 1. Get the ID from the URL parameter
+		id := ctx.Param("id")
+		var specialist models.Specialist
+
+		// 2. Find the first record that matches the ID.
+		if err := db.Preload("Programs").First(&specialist, id).Error; err != nil {
+			// Handle the case where no record found.
+			if err == gorm.ErrRecordNotFound {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Specialist not found"})
+			} else {
+				// Handle other database errors.
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch Specialist"})
+			}
+			return
+		}
+		ctx.JSON(http.StatusOK, toSpecialistResponse(specialist))
+	}
+}
+
+// SpecialistRequest defines the request body for creating or updating a specialist.
+type SpecialistRequest struct {
+	Name           string `json:"name" binding:"required,max=150"`
+	Bio            string `json:"bio"`
+	BioPL          string `json:"bio_pl"`
+	BioEN          string `json:"bio_en"`
+	BioUK          string `json:"bio_uk"`
+	Qualifications string `json:"qualifications"`
+	// Path or address of a media library image, "" removes the photo. Kept when omitted
+	Photo *string `json:"photo"`
+	// ID of a media library image, instead of photo
+	PhotoAsset *uint  `json:"photo_asset"`
+	Programs   []uint `json:"programs"`
+}
+
+// apply copies the request fields to the specialist model.
+// Empty translations are populated with the default language value.
+func (request SpecialistRequest) apply(specialist *models.Specialist) {
+	specialist.Name = request.Name
+	specialist.Bio = request.Bio
+	specialist.BioPL = request.BioPL
+	specialist.BioEN = request.BioEN
+	specialist.BioUK = request.BioUK
+	specialist.Qualifications = request.Qualifications
+	translation.FillDefaults(specialist)
+}
+
+// CreateSpecialist is the handler for creating a new specialist.
+func CreateSpecialist(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request SpecialistRequest
+		// 1. Bind the incoming JSON to the request struct.
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var specialist models.Specialist
+		request.apply(&specialist)
+		if err := requestImage(db, &specialist.Photo, request.Photo, request.PhotoAsset); err != nil {
+			imageError(ctx, err)
+			return
+		}
+		if err := findPrograms(db, request.Programs, &specialist.Programs); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// 2. Create the specialist together with the program links.
+		if err := db.Create(&specialist).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create Specialist"})
+			return
+		}
+		recordAudit(ctx, db, models.AuditCreate, &specialist, nil)
+		ctx.JSON(http.StatusCreated, toSpecialistResponse(specialist))
+	}
+}
+
+// UpdateSpecialist is the handler for updating a specialist and its programs.
+func UpdateSpecialist(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// 1. Get the ID from URL
+		id := ctx.Param("id")
+		var specialist models.Specialist
+		if err := db.Preload("Programs").First(&specialist, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Specialist not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database Error"})
+			}
+			return
+		}
+		before := auditState(specialist)
+		oldPhoto := specialist.Photo
+		// 2. Bind the incoming JSON to a request struct.
+		var request SpecialistRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		request.apply(&specialist)
+		if err := requestImage(db, &specialist.Photo, request.Photo, request.PhotoAsset); err != nil {
+			imageError(ctx, err)
+			return
+		}
+		var programs []models.Program
+		if err := findPrograms(db, request.Programs, &programs); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// 3. Save the specialist and replace the program links.
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit("Programs").Save(&specialist).Error; err != nil {
+				return err
+			}
+			return tx.Model(&specialist).Association("Programs").Replace(programs)
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update Specialist"})
+			return
+		}
+		specialist.Programs = programs
+		recordAudit(ctx, db, models.AuditUpdate, &specialist, before)
+		// A replaced photo nothing else shows is removed
+		releaseImages(db, oldPhoto)
+		ctx.JSON(http.StatusOK, toSpecialistResponse(specialist))
+	}
+}
+
+// DeleteSpecialist is the handler for deleting a specialist.
+func DeleteSpecialist(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// 1. Get the ID from URL
+		id := ctx.Param("id")
+		// 2. Find the specialist, its fields are kept in the audit log
+		var specialist models.Specialist
+		if err := db.Preload("Programs").First(&specialist, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Specialist not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database Error"})
+			}
+			return
+		}
+		before := auditState(specialist)
+		// 3. Delete the specialist
+		if err := db.Delete(&specialist).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete Specialist"})
+			return
+		}
+		recordAudit(ctx, db, models.AuditDelete, &specialist, before)
+		// 4. Send success response.
+		// The standard response for a successful DELETE is 204 No Content.
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// findPrograms loads the programs with the given IDs and fails if any of them does not exist.
+func findPrograms(db *gorm.DB, ids []uint, programs *[]models.Program) error {
+	*programs = nil
+	// Ignore repeated IDs
+	unique := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	if err := db.Find(programs, ids).Error; err != nil {
+		return err
+	}
+	if len(*programs) != len(unique) {
+		return errUnknownProgram
+	}
+	return nil
+}
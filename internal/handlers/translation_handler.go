@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// loadExtraTranslations returns the stored translations of the languages without
+// translation columns for a list of records, keyed by record ID.
+// Nothing is loaded when only pl/en/uk are enabled.
+func loadExtraTranslations(db *gorm.DB, model any, ids []uint) map[uint]translation.Values {
+	extraLanguages := translation.ExtraLanguages(model)
+	if len(extraLanguages) == 0 {
+		return nil
+	}
+	stored, err := translation.LoadMany(db, translation.EntityType(model), ids)
+	if err != nil {
+		log.Printf("Failed to load %s translations: %s", translation.EntityType(model), err)
+		return nil
+	}
+	extra := make(map[uint]translation.Values, len(stored))
+	for id, values := range stored {
+		extra[id] = translation.Only(values, extraLanguages)
+	}
+	return extra
+}
+
+// withTranslations adds the fields of the extra languages to the data of an admin form.
+func withTranslations(db *gorm.DB, model any, data gin.H) gin.H {
+	values := translation.Values{}
+	if id := translation.EntityID(model); id != 0 {
+		if stored := loadExtraTranslations(db, model, []uint{id})[id]; stored != nil {
+			values = stored
+		}
+	}
+	data["TranslationFields"] = translation.Fields(model)
+	data["ExtraLanguages"] = translation.ExtraLanguages(model)
+	data["Translations"] = values
+	return data
+}
+
+// storeFormTranslations saves the extra language fields submitted with an admin form.
+func storeFormTranslations(ctx *gin.Context, db *gorm.DB, model any) error {
+	return translation.Store(db, model, translation.FromForm(model, ctx.GetPostForm))
+}
+
+// allLanguages is the ?lang= value that selects the full multi-language response.
+const allLanguages = "all"
+
+// responseLanguage returns the language a response is localized to, taken from ?lang=
+// or negotiated from the Accept-Language header.
+// An empty language means the full multi-language shape: with ?lang=all, or when the
+// client states no preference at all.
+func responseLanguage(ctx *gin.Context) (string, error) {
+	ctx.Header("Vary", "Accept-Language")
+	lang := strings.ToLower(strings.TrimSpace(ctx.Query("lang")))
+	switch {
+	case lang == allLanguages:
+		return "", nil
+	case lang != "":
+		if !translation.Enabled(lang) {
+			return "", fmt.Errorf("unsupported language %q, use one of %s or %s",
+				lang, strings.Join(translation.Languages(), ", "), allLanguages)
+		}
+	case ctx.GetHeader("Accept-Language") != "":
+		lang = translation.Negotiate(ctx.GetHeader("Accept-Language"))
+	default:
+		return "", nil
+	}
+	ctx.Header("Content-Language", lang)
+	return lang, nil
+}
+
+// localizer renders records of one type in a single language.
+type localizer struct {
+	lang  string
+	extra map[uint]translation.Values
+}
+
+// newLocalizer loads the stored translations needed to localize the given records.
+func newLocalizer(db *gorm.DB, model any, ids []uint, lang string) localizer {
+	return localizer{lang: lang, extra: loadExtraTranslations(db, model, ids)}
+}
+
+// texts returns the translatable fields of a record in the localizer language,
+// falling back to the default language when a translation is empty.
+func (l localizer) texts(model any) map[string]string {
+	values := translation.Extract(model)
+	values.Merge(l.extra[translation.EntityID(model)])
+	return values.Localized(l.lang)
+}
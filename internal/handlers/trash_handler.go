@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/trash"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// trashSection is the list of deleted records of a type on the trash page.
+type trashSection struct {
+	Type  trash.Type
+	Items []trash.Item
+}
+
+// ShowTrashPage renders the deleted programs, prices and news.
+// retentionDays is the age after which the purge job removes them, 0 when it is disabled.
+func ShowTrashPage(db *gorm.DB, retentionDays int) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		sections := make([]trashSection, 0, len(trash.Types))
+		for _, t := range trash.Types {
+			items, err := trash.List(db, t)
+			if err != nil {
+				log.Printf("Failed to load deleted %s: %s", t.Name, err)
+				ctx.Status(http.StatusInternalServerError)
+				return
+			}
+			sections = append(sections, trashSection{Type: t, Items: items})
+		}
+		ctx.HTML(http.StatusOK, "trash.html", gin.H{
+			"Title":         "Trash",
+			"CSRFToken":     csrfToken(ctx),
+			"User":          session.Get("userName"),
+			"UserRole":      session.Get("userRole"),
+			"Sections":      sections,
+			"RetentionDays": retentionDays,
+		})
+	}
+}
+
+// findTrashed loads the deleted record named by the :type and :id URL parameters.
+func findTrashed(ctx *gin.Context, db *gorm.DB) (trash.Type, any, bool) {
+	t, ok := trash.Find(ctx.Param("type"))
+	if !ok {
+		ctx.Status(http.StatusNotFound)
+		return t, nil, false
+	}
+	record, err := trash.FindDeleted(db, t, ctx.Param("id"))
+	if err != nil {
+		log.Printf("Failed to find deleted %s with ID %s: %s", t.Name, ctx.Param("id"), err)
+		ctx.Status(http.StatusNotFound)
+		return t, nil, false
+	}
+	return t, record, true
+}
+
+// AdminRestoreTrash brings a deleted record back and removes its row from the trash page.
+func AdminRestoreTrash(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		t, record, ok := findTrashed(ctx, db)
+		if !ok {
+			return
+		}
+		before := auditState(record)
+		if err := trash.Restore(db, record); err != nil {
+			// Restoring can clash with a record created since, e.g. on a unique title
+			log.Printf("Failed to restore %s with ID %s: %s", t.Name, ctx.Param("id"), err)
+			ctx.Status(http.StatusConflict)
+			return
+		}
+		recordAudit(ctx, db, models.AuditRestore, record, before)
+		ctx.String(http.StatusOK, "")
+	}
+}
+
+// AdminPurgeTrash permanently deletes a record from the trash page.
+func AdminPurgeTrash(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		t, record, ok := findTrashed(ctx, db)
+		if !ok {
+			return
+		}
+		before := auditState(record)
+		if err := trash.Purge(db, t, record); err != nil {
+			log.Printf("Failed to purge %s with ID %s: %s", t.Name, ctx.Param("id"), err)
+			if errors.Is(err, trash.ErrInUse) {
+				ctx.Status(http.StatusConflict)
+			} else {
+				ctx.Status(http.StatusInternalServerError)
+			}
+			return
+		}
+		recordAudit(ctx, db, models.AuditPurge, record, before)
+		ctx.String(http.StatusOK, "")
+	}
+}
@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/twofactor"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// twoFactorLoginTimeout is how long the code may be entered after the password.
+const twoFactorLoginTimeout = 5 * time.Minute
+
+// twoFactorPage is the page where users turn on two-factor login.
+const twoFactorPage = "/admin/account/2fa/"
+
+// ShowTwoFactorLoginPage renders the second login step, asking for the code of the authenticator app.
+func ShowTwoFactorLoginPage(ctx *gin.Context) {
+	session := sessions.Default(ctx)
+	if _, ok := session.Get("pendingUserID").(uint); !ok {
+		ctx.Redirect(http.StatusFound, "/admin/login")
+		return
+	}
+	flashes := session.Flashes("error")
+	session.Save()
+	renderData := gin.H{"CSRFToken": csrfToken(ctx)}
+	if len(flashes) > 0 {
+		renderData["error"] = flashes[0]
+	}
+	ctx.HTML(http.StatusOK, "login-2fa.html", renderData)
+}
+
+// HandleTwoFactorLogin checks the code of the authenticator app, or a recovery code, of a
+// user who gave the right password. Wrong codes count as failed logins, like wrong passwords.
+func HandleTwoFactorLogin(db *gorm.DB, limits LoginLimits) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		now := time.Now()
+		user, ok := pendingUser(ctx, db, now)
+		if !ok {
+			session.Delete("pendingUserID")
+			session.Delete("pendingSince")
+			session.AddFlash("The login has expired, log in again", "error")
+			session.Save()
+			ctx.Redirect(http.StatusFound, "/admin/login")
+			return
+		}
+		ip := ctx.ClientIP()
+		wait, err := loginDelay(db, user.UserName, ip, now)
+		if err != nil {
+			log.Printf("Failed to check failed logins: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if wait > 0 {
+			wait = wait.Truncate(time.Second) + time.Second
+			session.AddFlash(fmt.Sprintf("Too many failed logins, try again in %s", wait), "error")
+			session.Save()
+			ctx.Redirect(http.StatusFound, "/admin/login/2fa")
+			return
+		}
+		if user.IsLocked(now) {
+			session.Delete("pendingUserID")
+			session.Delete("pendingSince")
+			session.AddFlash("This account is locked after too many failed logins, try again later", "error")
+			session.Save()
+			ctx.Redirect(http.StatusFound, "/admin/login")
+			return
+		}
+
+		if err := twofactor.Verify(db, &user, ctx.PostForm("code"), now); err != nil {
+			if !errors.Is(err, twofactor.ErrInvalidCode) {
+				log.Printf("Failed to check the code of user %d: %s", user.ID, err)
+				ctx.Status(http.StatusInternalServerError)
+				return
+			}
+			recordFailedLogin(db, user.UserName, ip, &user)
+			countFailedLogin(db, &user, limits, now)
+			session.AddFlash("Invalid code", "error")
+			session.Save()
+			ctx.Redirect(http.StatusFound, "/admin/login/2fa")
+			return
+		}
+		startSession(ctx, db, &user, true)
+	}
+}
+
+// pendingUser returns the user who gave the right password and has yet to enter the code.
+func pendingUser(ctx *gin.Context, db *gorm.DB, now time.Time) (models.User, bool) {
+	session := sessions.Default(ctx)
+	var user models.User
+	id, ok := session.Get("pendingUserID").(uint)
+	since, _ := session.Get("pendingSince").(int64)
+	if !ok || now.Sub(time.Unix(since, 0)) > twoFactorLoginTimeout {
+		return user, false
+	}
+	if err := db.First(&user, id).Error; err != nil {
+		log.Printf("Failed to find User with ID %d: %s", id, err)
+		return user, false
+	}
+	// Disabled meanwhile or two-factor login reset, the password has to be given again
+	return user, !user.IsDisabled() && user.HasTwoFactor()
+}
+
+// TwoFactorRequired is a middleware sending the users of the given roles who log in
+// without an authenticator app to the page turning it on, until they do.
+func TwoFactorRequired(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		role, _ := session.Get("userRole").(string)
+		enrolled, _ := session.Get("twoFactor").(bool)
+		path := ctx.Request.URL.Path
+		if enrolled || !slices.Contains(roles, role) || strings.HasPrefix(path, twoFactorPage) || path == "/admin/logout" {
+			ctx.Next()
+			return
+		}
+		if ctx.GetHeader("HX-Request") == "true" {
+			ctx.Header("HX-Redirect", twoFactorPage)
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		ctx.Redirect(http.StatusFound, twoFactorPage)
+		ctx.Abort()
+	}
+}
+
+// ShowTwoFactorPage renders the two-factor login settings of the logged in user.
+// Without two-factor login it shows the key to add to an authenticator app, the same
+// one until it is enabled or the user starts over, see RestartTwoFactor.
+func ShowTwoFactorPage(db *gorm.DB, requiredRoles []string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := sessionUser(ctx, db)
+		if !ok {
+			return
+		}
+		session := sessions.Default(ctx)
+		flashes := session.Flashes("error")
+		renderData := twoFactorPageData(ctx, user, requiredRoles)
+		if len(flashes) > 0 {
+			renderData["error"] = flashes[0]
+		}
+		if user.HasTwoFactor() {
+			session.Save()
+			remaining, err := twofactor.RemainingRecoveryCodes(db, user.ID)
+			if err != nil {
+				log.Printf("Failed to count recovery codes of user %d: %s", user.ID, err)
+			}
+			renderData["RemainingCodes"] = remaining
+			ctx.HTML(http.StatusOK, "two-factor.html", renderData)
+			return
+		}
+
+		session.Save()
+		// The key is only enabled once a code of it was entered
+		key, err := twofactor.Enrollment(db, &user)
+		if err != nil {
+			log.Printf("Failed to generate two-factor key: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		qrCode, err := twofactor.QRCode(key)
+		if err != nil {
+			log.Printf("Failed to render two-factor QR code: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		renderData["QRCode"] = qrCode
+		renderData["Secret"] = key.Secret()
+		ctx.HTML(http.StatusOK, "two-factor.html", renderData)
+	}
+}
+
+// EnableTwoFactor turns on two-factor login once the user entered a code of the new key,
+// showing the recovery codes once.
+func EnableTwoFactor(db *gorm.DB, requiredRoles []string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := sessionUser(ctx, db)
+		if !ok {
+			return
+		}
+		key, err := twofactor.PendingKey(user)
+		if user.HasTwoFactor() || errors.Is(err, twofactor.ErrNoPendingKey) {
+			ctx.Redirect(http.StatusFound, twoFactorPage)
+			return
+		} else if err != nil {
+			log.Printf("Failed to read pending two-factor key of user %d: %s", user.ID, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		now := time.Now()
+		firstStep, err := twofactor.CheckKey(key, ctx.PostForm("code"), now)
+		if err != nil {
+			// Show the same key again, it may just have been a typo
+			qrCode, _ := twofactor.QRCode(key)
+			renderData := twoFactorPageData(ctx, user, requiredRoles)
+			renderData["QRCode"] = qrCode
+			renderData["Secret"] = key.Secret()
+			renderData["error"] = "Invalid code, check the time of the phone and try again"
+			ctx.HTML(http.StatusUnprocessableEntity, "two-factor.html", renderData)
+			return
+		}
+
+		before := auditState(user)
+		codes, err := twofactor.Enable(db, &user, key, firstStep, now)
+		if err != nil {
+			log.Printf("Failed to enable two-factor login of user %d: %s", user.ID, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditUpdate, &user, before)
+		session := sessions.Default(ctx)
+		session.Set("twoFactor", true)
+		if err := session.Save(); err != nil {
+			log.Printf("Failed to save session: %s", err)
+		}
+		renderData := twoFactorPageData(ctx, user, requiredRoles)
+		renderData["RecoveryCodes"] = codes
+		renderData["RemainingCodes"] = len(codes)
+		ctx.HTML(http.StatusOK, "two-factor.html", renderData)
+	}
+}
+
+// RestartTwoFactor replaces the key a user is enrolling with a new one, e.g. when the
+// QR code was scanned into a lost phone.
+func RestartTwoFactor(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := sessionUser(ctx, db)
+		if !ok {
+			return
+		}
+		if !user.HasTwoFactor() {
+			if _, err := twofactor.Begin(db, &user); err != nil {
+				log.Printf("Failed to generate two-factor key: %s", err)
+				ctx.Status(http.StatusInternalServerError)
+				return
+			}
+		}
+		ctx.Redirect(http.StatusFound, twoFactorPage)
+	}
+}
+
+// DisableTwoFactor turns off two-factor login after checking a current code,
+// unless the role of the user requires it.
+func DisableTwoFactor(db *gorm.DB, requiredRoles []string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := sessionUser(ctx, db)
+		if !ok {
+			return
+		}
+		session := sessions.Default(ctx)
+		if slices.Contains(requiredRoles, user.Role) {
+			session.AddFlash("Two-factor login is required for your role", "error")
+			session.Save()
+			ctx.Redirect(http.StatusFound, twoFactorPage)
+			return
+		}
+		if !checkTwoFactorCode(ctx, db, &user) {
+			return
+		}
+		before := auditState(user)
+		if err := twofactor.Disable(db, &user); err != nil {
+			log.Printf("Failed to disable two-factor login of user %d: %s", user.ID, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		recordAudit(ctx, db, models.AuditUpdate, &user, before)
+		session.Set("twoFactor", false)
+		session.Save()
+		ctx.Redirect(http.StatusFound, twoFactorPage)
+	}
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user after checking a
+// current code, showing the new ones once.
+func RegenerateRecoveryCodes(db *gorm.DB, requiredRoles []string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := sessionUser(ctx, db)
+		if !ok {
+			return
+		}
+		if !user.HasTwoFactor() {
+			ctx.Redirect(http.StatusFound, twoFactorPage)
+			return
+		}
+		if !checkTwoFactorCode(ctx, db, &user) {
+			return
+		}
+		codes, err := twofactor.RegenerateRecoveryCodes(db, &user)
+		if err != nil {
+			log.Printf("Failed to regenerate recovery codes of user %d: %s", user.ID, err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		log.Printf("User %s regenerated the recovery codes", user.UserName)
+		renderData := twoFactorPageData(ctx, user, requiredRoles)
+		renderData["RecoveryCodes"] = codes
+		renderData["RemainingCodes"] = len(codes)
+		ctx.HTML(http.StatusOK, "two-factor.html", renderData)
+	}
+}
+
+// checkTwoFactorCode checks the code confirming a change of the two-factor settings.
+// On a wrong code it flashes an error, redirects back and returns false.
+func checkTwoFactorCode(ctx *gin.Context, db *gorm.DB, user *models.User) bool {
+	err := twofactor.Verify(db, user, ctx.PostForm("code"), time.Now())
+	if err == nil {
+		return true
+	}
+	if !errors.Is(err, twofactor.ErrInvalidCode) {
+		log.Printf("Failed to check the code of user %d: %s", user.ID, err)
+		ctx.Status(http.StatusInternalServerError)
+		return false
+	}
+	session := sessions.Default(ctx)
+	session.AddFlash("Invalid code", "error")
+	session.Save()
+	ctx.Redirect(http.StatusFound, twoFactorPage)
+	return false
+}
+
+// sessionUser loads the logged in user. When that fails it responds and returns false.
+func sessionUser(ctx *gin.Context, db *gorm.DB) (models.User, bool) {
+	var user models.User
+	id, ok := sessions.Default(ctx).Get("userID").(uint)
+	if !ok {
+		ctx.Redirect(http.StatusFound, "/admin/login")
+		return user, false
+	}
+	if err := db.First(&user, id).Error; err != nil {
+		log.Printf("Failed to find User with ID %d: %s", id, err)
+		ctx.Status(http.StatusNotFound)
+		return user, false
+	}
+	return user, true
+}
+
+// twoFactorPageData returns the data shared by the renderings of the two-factor page.
+func twoFactorPageData(ctx *gin.Context, user models.User, requiredRoles []string) gin.H {
+	session := sessions.Default(ctx)
+	return gin.H{
+		"Title":     "Two-factor login",
+		"CSRFToken": csrfToken(ctx),
+		"User":      session.Get("userName"),
+		"UserRole":  session.Get("userRole"),
+		"Enabled":   user.HasTwoFactor(),
+		"Required":  slices.Contains(requiredRoles, user.Role),
+	}
+}
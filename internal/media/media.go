@@ -0,0 +1,329 @@
+// Package media keeps track of the uploaded images in the media library.
+package media
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/database"
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"github.com/DmytroPI-dev/clinic-golang/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ErrInUse is returned when an image is still shown by a record.
+var ErrInUse = errors.New("image is still in use")
+
+// ErrDuplicate is returned when an uploaded image is already in the media library.
+var ErrDuplicate = errors.New("image is already in the media library")
+
+// Save processes an uploaded image and adds it to the media library with its variants.
+// The path, name, dimensions and size of the asset are filled in, its alt text,
+// uploader and pin are kept. When the same file is in the library already, nothing
+// is saved, the asset is replaced by the existing one and ErrDuplicate is returned.
+func Save(db *gorm.DB, file *multipart.FileHeader, asset *models.MediaAsset) error {
+	hash, err := utils.UploadHash(file)
+	if err != nil {
+		return err
+	}
+	if existing, err := findByHash(db, hash); err != nil || existing != nil {
+		if existing != nil {
+			*asset = *existing
+			return ErrDuplicate
+		}
+		return err
+	}
+	saved, err := utils.ProcessAndSaveImages(file)
+	if err != nil {
+		removeFiles(saved)
+		return err
+	}
+	asset.Path = saved.Path
+	asset.Hash = saved.Hash
+	asset.OriginalName = file.Filename
+	asset.Width = saved.Width
+	asset.Height = saved.Height
+	asset.Size = saved.Size
+	asset.Variants = variants(saved)
+	if err := db.Create(asset).Error; err != nil {
+		// The same file uploaded at the same time wrote the same files, they are its files now
+		if existing, _ := findByHash(db, hash); existing != nil {
+			*asset = *existing
+			return ErrDuplicate
+		}
+		// Without its asset the files would be lost in the upload storage
+		removeFiles(saved)
+		return err
+	}
+	return nil
+}
+
+// findByHash returns the asset of an uploaded file, nil when it is not in the library.
+func findByHash(db *gorm.DB, hash string) (*models.MediaAsset, error) {
+	var assets []models.MediaAsset
+	if err := db.Where("hash = ?", hash).Limit(1).Find(&assets).Error; err != nil || len(assets) == 0 {
+		return nil, err
+	}
+	return &assets[0], nil
+}
+
+// Regenerate makes the variants of an asset again, e.g. after the configured widths
+// changed, and replaces the recorded ones. Files of dropped variants are removed.
+func Regenerate(db *gorm.DB, asset *models.MediaAsset) error {
+	saved, err := utils.RegenerateImage(asset.Path)
+	if err != nil {
+		return err
+	}
+	var old []models.MediaVariant
+	if err := db.Where("media_asset_id = ?", asset.ID).Find(&old).Error; err != nil {
+		return err
+	}
+	asset.Width, asset.Height, asset.Size = saved.Width, saved.Height, saved.Size
+	asset.Variants = variants(saved)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("media_asset_id = ?", asset.ID).Delete(&models.MediaVariant{}).Error; err != nil {
+			return err
+		}
+		for i := range asset.Variants {
+			asset.Variants[i].MediaAssetID = asset.ID
+		}
+		if len(asset.Variants) > 0 {
+			if err := tx.Create(&asset.Variants).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(asset).Select("width", "height", "size").Updates(asset).Error
+	})
+	if err != nil {
+		return err
+	}
+	kept := map[string]bool{asset.Path: true}
+	for _, variant := range asset.Variants {
+		kept[variant.Path] = true
+	}
+	for _, variant := range old {
+		if !kept[variant.Path] {
+			utils.RemoveImage(variant.Path)
+		}
+	}
+	return nil
+}
+
+// variants returns the variants of a saved image as they are recorded.
+func variants(saved utils.SavedImage) []models.MediaVariant {
+	recorded := make([]models.MediaVariant, 0, len(saved.Variants))
+	for _, variant := range saved.Variants {
+		recorded = append(recorded, models.MediaVariant{
+			Path:   variant.Path,
+			Format: variant.Format,
+			Width:  variant.Width,
+			Height: variant.Height,
+			Size:   variant.Size,
+		})
+	}
+	return recorded
+}
+
+// removeFiles deletes the files of a saved image that could not be recorded.
+func removeFiles(saved utils.SavedImage) {
+	if saved.Path != "" {
+		utils.RemoveImage(saved.Path)
+	}
+	for _, variant := range saved.Variants {
+		utils.RemoveImage(variant.Path)
+	}
+}
+
+// usages are the columns holding image paths, by model. Every column showing
+// uploaded images belongs here, so its images are counted by References.
+var usages = []struct {
+	model   any
+	columns []string
+}{
+	{&models.News{}, []string{"image_left", "image_right"}},
+	{&models.Category{}, []string{"icon"}},
+	{&models.Specialist{}, []string{"photo"}},
+}
+
+// revisionBatchSize is the number of revisions decoded at once by UsedPaths.
+const revisionBatchSize = 500
+
+// UsedPaths returns the image paths shown by the content, deleted records included,
+// as they may still be restored from the trash, and the paths kept in revisions,
+// as they may still be restored from the history.
+func UsedPaths(db *gorm.DB) (map[string]bool, error) {
+	used := map[string]bool{}
+	for _, usage := range usages {
+		for _, column := range usage.columns {
+			var paths []string
+			if err := db.Unscoped().Model(usage.model).Where(column+" <> ''").Distinct().Pluck(column, &paths).Error; err != nil {
+				return nil, err
+			}
+			for _, path := range paths {
+				used[path] = true
+			}
+		}
+	}
+	paths, err := revisionPaths(db, db)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		used[path] = true
+	}
+	return used, nil
+}
+
+// RevisionPaths returns the image paths kept in the revisions of a record,
+// e.g. to release them when the record and its history are purged.
+func RevisionPaths(db *gorm.DB, record any) ([]string, error) {
+	query := db.Where("entity_type = ? AND entity_id = ?", translation.EntityType(record), translation.EntityID(record))
+	return revisionPaths(db, query)
+}
+
+// revisionPaths decodes the image paths kept in the revisions matching a query.
+// A revision stores its record as JSON, keyed by the field names.
+func revisionPaths(db, query *gorm.DB) ([]string, error) {
+	fields := map[string][]string{}
+	entityTypes := make([]string, 0, len(usages))
+	for _, usage := range usages {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(usage.model); err != nil {
+			return nil, err
+		}
+		entityType := translation.EntityType(usage.model)
+		for _, column := range usage.columns {
+			if field := stmt.Schema.LookUpField(column); field != nil {
+				fields[entityType] = append(fields[entityType], field.Name)
+			}
+		}
+		entityTypes = append(entityTypes, entityType)
+	}
+
+	seen := map[string]bool{}
+	var paths []string
+	var revisions []models.Revision
+	err := query.Model(&models.Revision{}).Select("id", "entity_type", "data").
+		Where("entity_type IN ?", entityTypes).
+		FindInBatches(&revisions, revisionBatchSize, func(*gorm.DB, int) error {
+			for _, revision := range revisions {
+				var data map[string]any
+				if err := json.Unmarshal([]byte(revision.Data), &data); err != nil {
+					continue
+				}
+				for _, field := range fields[revision.EntityType] {
+					if path, ok := data[field].(string); ok && path != "" && !seen[path] {
+						seen[path] = true
+						paths = append(paths, path)
+					}
+				}
+			}
+			return nil
+		}).Error
+	return paths, err
+}
+
+// References returns the number of records showing an image, deleted ones included,
+// as they may still be restored from the trash. Revisions holding the image count
+// too, so restoring an older revision does not bring back a missing file.
+func References(db *gorm.DB, path string) (int64, error) {
+	var total int64
+	entityTypes := make([]string, 0, len(usages))
+	for _, usage := range usages {
+		for _, column := range usage.columns {
+			var count int64
+			if err := db.Unscoped().Model(usage.model).Where(column+" = ?", path).Count(&count).Error; err != nil {
+				return 0, err
+			}
+			total += count
+		}
+		entityTypes = append(entityTypes, translation.EntityType(usage.model))
+	}
+	// The path as a JSON string, a false match only keeps a file longer
+	encoded, err := json.Marshal(path)
+	if err != nil {
+		return 0, err
+	}
+	var revisions int64
+	err = db.Model(&models.Revision{}).
+		Where("entity_type IN ?", entityTypes).
+		Where(database.Like(db, "data"), "%"+database.EscapeLike(string(encoded))+"%").
+		Count(&revisions).Error
+	if err != nil {
+		return 0, err
+	}
+	return total + revisions, nil
+}
+
+// InUse reports whether an image is shown by a record, deleted or not.
+// Errors count as in use, so a file is never removed by mistake.
+func InUse(db *gorm.DB, path string) bool {
+	count, err := References(db, path)
+	return err != nil || count > 0
+}
+
+// Release removes the images no record shows anymore, e.g. the ones an update replaced
+// or those of a purged record. Images pinned to the media library are kept, and so are
+// the addresses outside the uploads.
+func Release(db *gorm.DB, paths ...string) error {
+	var errs []error
+	for _, path := range paths {
+		if _, err := storage.Name(path); err != nil || InUse(db, path) {
+			continue
+		}
+		var pinned int64
+		if err := db.Model(&models.MediaAsset{}).Where("path = ? AND pinned = ?", path, true).Count(&pinned).Error; err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if pinned > 0 {
+			continue
+		}
+		if err := Remove(db, path); err != nil && !errors.Is(err, ErrInUse) {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Remove deletes an image file and its media library asset, with the asset variants
+// and translations. Images still in use are kept and ErrInUse is returned.
+func Remove(db *gorm.DB, path string) error {
+	if InUse(db, path) {
+		return ErrInUse
+	}
+	var files []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&models.MediaAsset{}).Where("path = ?", path).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.MediaVariant{}).Where("media_asset_id IN ?", ids).Pluck("path", &files).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("media_asset_id IN ?", ids).Delete(&models.MediaVariant{}).Error; err != nil {
+			return err
+		}
+		entityType := translation.EntityType(&models.MediaAsset{})
+		if err := tx.Where("entity_type = ? AND entity_id IN ?", entityType, ids).Delete(&models.Translation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.MediaAsset{}, ids).Error
+	})
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file != path {
+			utils.RemoveImage(file)
+		}
+	}
+	return utils.RemoveImage(path)
+}
@@ -0,0 +1,172 @@
+package media
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/database"
+	"github.com/DmytroPI-dev/clinic-golang/internal/migrations"
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"gorm.io/gorm"
+)
+
+// newTestDB returns an in-memory SQLite database with every migration applied.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.DB_Connect(database.DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.New(db).Up(0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// useTempStorage keeps the uploads of a test in a temporary directory.
+func useTempStorage(t *testing.T) storage.Storage {
+	t.Helper()
+	previous := storage.Current()
+	t.Cleanup(func() { storage.Configure(previous) })
+	backend := storage.NewLocal(t.TempDir(), "/uploads")
+	storage.Configure(backend)
+	return backend
+}
+
+// createAsset writes the files of an image with a WebP variant and records its asset.
+func createAsset(t *testing.T, db *gorm.DB, backend storage.Storage, name string, pinned bool) models.MediaAsset {
+	t.Helper()
+	asset := models.MediaAsset{
+		Path:   storage.Path(name + ".jpg"),
+		Hash:   name,
+		Pinned: pinned,
+		Variants: []models.MediaVariant{
+			{Path: storage.Path(name + ".jpg"), Format: "jpeg"},
+			{Path: storage.Path(name + ".webp"), Format: "webp"},
+		},
+	}
+	for _, variant := range asset.Variants {
+		name, _ := storage.Name(variant.Path)
+		if err := backend.Put(name, bytes.NewReader([]byte("image")), 5, "image/"+variant.Format); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Create(&asset).Error; err != nil {
+		t.Fatal(err)
+	}
+	return asset
+}
+
+// storedFiles returns the names of the files in the storage.
+func storedFiles(t *testing.T, backend storage.Storage) []string {
+	t.Helper()
+	names, err := backend.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestReleaseRemovesUnusedImage(t *testing.T) {
+	db := newTestDB(t)
+	backend := useTempStorage(t)
+	asset := createAsset(t, db, backend, "unused", false)
+
+	if err := Release(db, asset.Path); err != nil {
+		t.Fatal(err)
+	}
+	if files := storedFiles(t, backend); len(files) != 0 {
+		t.Fatalf("files %v are left over", files)
+	}
+	var assets, variants int64
+	db.Model(&models.MediaAsset{}).Count(&assets)
+	db.Model(&models.MediaVariant{}).Count(&variants)
+	if assets != 0 || variants != 0 {
+		t.Fatalf("%d assets and %d variants are left over", assets, variants)
+	}
+}
+
+func TestReleaseKeepsImagesInUse(t *testing.T) {
+	db := newTestDB(t)
+	backend := useTempStorage(t)
+	shown := createAsset(t, db, backend, "shown", false)
+	trashed := createAsset(t, db, backend, "trashed", false)
+	pinned := createAsset(t, db, backend, "pinned", true)
+
+	news := models.News{Title: "Opening", ImageLeft: shown.Path}
+	deleted := models.Specialist{Name: "Former", Photo: trashed.Path}
+	if err := db.Create(&news).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	// A deleted record may still be restored from the trash
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Release(db, shown.Path, trashed.Path, pinned.Path); err != nil {
+		t.Fatal(err)
+	}
+	if files := storedFiles(t, backend); len(files) != 6 {
+		t.Fatalf("got files %v, want all six kept", files)
+	}
+}
+
+func TestReleaseKeepsImagesOfRevisions(t *testing.T) {
+	db := newTestDB(t)
+	backend := useTempStorage(t)
+	replaced := createAsset(t, db, backend, "replaced", false)
+
+	// The news item shows another image now, an older revision still has this one
+	news := models.News{Title: "Opening"}
+	if err := db.Create(&news).Error; err != nil {
+		t.Fatal(err)
+	}
+	revision := models.Revision{EntityType: translation.EntityType(&news), EntityID: news.ID, Data: `{"Title":"Opening","ImageLeft":"` + replaced.Path + `"}`}
+	if err := db.Create(&revision).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Release(db, replaced.Path); err != nil {
+		t.Fatal(err)
+	}
+	if files := storedFiles(t, backend); len(files) != 2 {
+		t.Fatalf("got files %v, want the revision image kept", files)
+	}
+	used, err := UsedPaths(db)
+	if err != nil || !used[replaced.Path] {
+		t.Fatalf("got used paths %v, %v without the revision image", used, err)
+	}
+	paths, err := RevisionPaths(db, &news)
+	if err != nil || !slices.Contains(paths, replaced.Path) {
+		t.Fatalf("got revision paths %v, %v", paths, err)
+	}
+
+	// Once the revisions are gone, so is the image
+	if err := db.Delete(&revision).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Release(db, replaced.Path); err != nil {
+		t.Fatal(err)
+	}
+	if files := storedFiles(t, backend); len(files) != 0 {
+		t.Fatalf("files %v are left over", files)
+	}
+}
+
+func TestReleaseIgnoresOtherAddresses(t *testing.T) {
+	db := newTestDB(t)
+	useTempStorage(t)
+	if err := Release(db, "", "https://example.com/photo.jpg", "/static/logo.png"); err != nil {
+		t.Fatal(err)
+	}
+}
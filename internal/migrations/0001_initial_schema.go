@@ -0,0 +1,249 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The tables as they were when versioned migrations were introduced. Later columns
+// are added by their own migrations, so these structs must not follow the models.
+
+type initialProgram struct {
+	gorm.Model
+	Title         string         `gorm:"size:250;unique"`
+	Description   string         `gorm:"type:text"`
+	Results       string         `gorm:"type:text"`
+	TitlePL       string         `gorm:"size:250;column:title_pl"`
+	DescriptionPL string         `gorm:"type:text;column:description_pl"`
+	ResultsPL     string         `gorm:"type:text;column:results_pl"`
+	TitleEN       string         `gorm:"size:250;column:title_en"`
+	DescriptionEN string         `gorm:"type:text;column:description_en"`
+	ResultsEN     string         `gorm:"type:text;column:results_en"`
+	TitleUK       string         `gorm:"size:250;column:title_uk"`
+	DescriptionUK string         `gorm:"type:text;column:description_uk"`
+	ResultsUK     string         `gorm:"type:text;column:results_uk"`
+	Category      string         `gorm:"size:2"`
+	Duration      int            `gorm:"default:60"`
+	Prices        []initialPrice `gorm:"foreignKey:ProgramID"`
+}
+
+func (initialProgram) TableName() string {
+	return "programs"
+}
+
+type initialPrice struct {
+	gorm.Model
+	ItemName   string `gorm:"size:150;unique"`
+	Price      float32
+	ItemNamePL string `gorm:"size:150;column:item_name_pl"`
+	ItemNameEN string `gorm:"size:150;column:item_name_en"`
+	ItemNameUK string `gorm:"size:150;column:item_name_uk"`
+	Category   string `gorm:"size:2"`
+	ProgramID  *uint  `gorm:"index"`
+	Program    *initialProgram
+	Variant    string `gorm:"size:20;default:single"`
+	Sessions   int    `gorm:"default:1"`
+	Area       string `gorm:"size:50"`
+}
+
+func (initialPrice) TableName() string {
+	return "prices"
+}
+
+type initialNews struct {
+	gorm.Model
+	Title         string `gorm:"size:250;unique"`
+	Header        string `gorm:"type:text"`
+	Description   string `gorm:"type:text"`
+	Features      string `gorm:"type:text"`
+	PostedOn      time.Time
+	TitlePL       string `gorm:"size:250;column:title_pl"`
+	DescriptionPL string `gorm:"type:text;column:description_pl"`
+	HeaderPL      string `gorm:"type:text;column:header_pl"`
+	FeaturesPL    string `gorm:"type:text;column:features_pl"`
+	TitleEN       string `gorm:"size:250;column:title_en"`
+	DescriptionEN string `gorm:"type:text;column:description_en"`
+	HeaderEN      string `gorm:"type:text;column:header_en"`
+	FeaturesEN    string `gorm:"type:text;column:features_en"`
+	TitleUK       string `gorm:"size:250;column:title_uk"`
+	DescriptionUK string `gorm:"type:text;column:description_uk"`
+	HeaderUK      string `gorm:"type:text;column:header_uk"`
+	FeaturesUK    string `gorm:"type:text;column:features_uk"`
+	ImageLeft     string `gorm:"type:text"`
+	ImageRight    string `gorm:"type:text"`
+}
+
+func (initialNews) TableName() string {
+	return "news"
+}
+
+type initialUser struct {
+	gorm.Model
+	UserName     string `gorm:"size:100;unique"`
+	PasswordHash string `gorm:"size:255"`
+	Email        string `gorm:"size:255;unique"`
+	Role         string `gorm:"size:50"`
+}
+
+func (initialUser) TableName() string {
+	return "users"
+}
+
+type initialAPIToken struct {
+	gorm.Model
+	Name       string `gorm:"size:100"`
+	TokenHash  string `gorm:"size:64;uniqueIndex"`
+	Prefix     string `gorm:"size:12"`
+	Scopes     string `gorm:"size:255"`
+	UserID     uint
+	User       initialUser
+	LastUsedAt *time.Time
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+}
+
+func (initialAPIToken) TableName() string {
+	return "api_tokens"
+}
+
+type initialAppointment struct {
+	gorm.Model
+	ProgramID    uint `gorm:"index"`
+	Program      initialProgram
+	PatientName  string    `gorm:"size:150"`
+	PatientPhone string    `gorm:"size:30"`
+	PatientEmail string    `gorm:"size:255"`
+	RequestedAt  time.Time `gorm:"index"`
+	Status       string    `gorm:"size:20;index;default:pending"`
+	Notes        string    `gorm:"type:text"`
+}
+
+func (initialAppointment) TableName() string {
+	return "appointments"
+}
+
+type initialSpecialist struct {
+	gorm.Model
+	Name           string `gorm:"size:150"`
+	Bio            string `gorm:"type:text"`
+	Qualifications string `gorm:"type:text"`
+	BioPL          string `gorm:"type:text;column:bio_pl"`
+	BioEN          string `gorm:"type:text;column:bio_en"`
+	BioUK          string `gorm:"type:text;column:bio_uk"`
+	Photo          string `gorm:"type:text"`
+}
+
+func (initialSpecialist) TableName() string {
+	return "specialists"
+}
+
+// initialSpecialistProgram is the join table of specialists and their programs.
+type initialSpecialistProgram struct {
+	SpecialistID uint `gorm:"primaryKey;autoIncrement:false"`
+	ProgramID    uint `gorm:"primaryKey;autoIncrement:false"`
+	Specialist   initialSpecialist
+	Program      initialProgram
+}
+
+func (initialSpecialistProgram) TableName() string {
+	return "specialist_programs"
+}
+
+type initialOpeningHours struct {
+	gorm.Model
+	Weekday  int    `gorm:"uniqueIndex"`
+	OpensAt  string `gorm:"size:5"`
+	ClosesAt string `gorm:"size:5"`
+	Closed   bool
+}
+
+func (initialOpeningHours) TableName() string {
+	return "opening_hours"
+}
+
+type initialSpecialistSchedule struct {
+	gorm.Model
+	SpecialistID uint `gorm:"index"`
+	Specialist   initialSpecialist
+	Weekday      int
+	StartsAt     string `gorm:"size:5"`
+	EndsAt       string `gorm:"size:5"`
+}
+
+func (initialSpecialistSchedule) TableName() string {
+	return "specialist_schedules"
+}
+
+type initialScheduleException struct {
+	gorm.Model
+	SpecialistID *uint `gorm:"index"`
+	Specialist   *initialSpecialist
+	StartsOn     time.Time `gorm:"type:date"`
+	EndsOn       time.Time `gorm:"type:date"`
+	Reason       string    `gorm:"size:250"`
+}
+
+func (initialScheduleException) TableName() string {
+	return "schedule_exceptions"
+}
+
+type initialCategory struct {
+	gorm.Model
+	Code      string `gorm:"size:2;uniqueIndex"`
+	Slug      string `gorm:"size:100;uniqueIndex"`
+	SortOrder int
+	Name      string `gorm:"size:150"`
+	NamePL    string `gorm:"size:150;column:name_pl"`
+	NameEN    string `gorm:"size:150;column:name_en"`
+	NameUK    string `gorm:"size:150;column:name_uk"`
+	Icon      string `gorm:"type:text"`
+}
+
+func (initialCategory) TableName() string {
+	return "categories"
+}
+
+type initialTranslation struct {
+	ID         uint   `gorm:"primarykey"`
+	EntityType string `gorm:"size:50;uniqueIndex:idx_translation_key"`
+	EntityID   uint   `gorm:"uniqueIndex:idx_translation_key"`
+	Language   string `gorm:"size:10;uniqueIndex:idx_translation_key"`
+	Field      string `gorm:"size:50;uniqueIndex:idx_translation_key"`
+	Value      string `gorm:"type:text"`
+	UpdatedAt  time.Time
+}
+
+func (initialTranslation) TableName() string {
+	return "translations"
+}
+
+// initialSchema are the tables created by AutoMigrate before versioned migrations.
+// On an existing database the migration only adds what is missing.
+func initialSchema() []any {
+	return []any{
+		&initialProgram{}, &initialPrice{}, &initialNews{}, &initialUser{}, &initialAPIToken{},
+		&initialAppointment{}, &initialSpecialist{}, &initialSpecialistProgram{}, &initialOpeningHours{},
+		&initialSpecialistSchedule{}, &initialScheduleException{}, &initialCategory{}, &initialTranslation{},
+	}
+}
+
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(initialSchema()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			tables := initialSchema()
+			// In reverse order of their dependencies
+			for i := len(tables) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(tables[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
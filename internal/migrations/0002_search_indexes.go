@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// searchIndex is a FULLTEXT index created by this migration.
+type searchIndex struct {
+	Table   string
+	Name    string
+	Columns []string
+}
+
+// searchIndexes are the indexes of the search API when it was added. Only MySQL
+// has FULLTEXT, the other databases are searched with LIKE.
+var searchIndexes = []searchIndex{
+	{
+		Table: "programs",
+		Name:  "idx_programs_search",
+		Columns: []string{
+			"title", "title_pl", "title_en", "title_uk",
+			"description", "description_pl", "description_en", "description_uk",
+			"results", "results_pl", "results_en", "results_uk",
+		},
+	},
+	{
+		Table:   "prices",
+		Name:    "idx_prices_search",
+		Columns: []string{"item_name", "item_name_pl", "item_name_en", "item_name_uk"},
+	},
+	{
+		Table: "news",
+		Name:  "idx_news_search",
+		Columns: []string{
+			"title", "title_pl", "title_en", "title_uk",
+			"description", "description_pl", "description_en", "description_uk",
+			"features", "features_pl", "features_en", "features_uk",
+		},
+	},
+}
+
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "search_indexes",
+		// Databases from before the versioned migrations may have the indexes already
+		Up: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			for _, index := range searchIndexes {
+				if tx.Migrator().HasIndex(index.Table, index.Name) {
+					continue
+				}
+				statement := fmt.Sprintf("CREATE FULLTEXT INDEX %s ON %s (%s)", index.Name, index.Table, strings.Join(index.Columns, ", "))
+				if err := tx.Exec(statement).Error; err != nil {
+					return fmt.Errorf("creating %s: %w", index.Name, err)
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			for _, index := range searchIndexes {
+				if !tx.Migrator().HasIndex(index.Table, index.Name) {
+					continue
+				}
+				if err := tx.Migrator().DropIndex(index.Table, index.Name); err != nil {
+					return fmt.Errorf("dropping %s: %w", index.Name, err)
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seedCategory is the categories table as the seed rows fill it.
+type seedCategory struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+	Code      string
+	Slug      string
+	SortOrder int
+	Name      string
+	NamePL    string `gorm:"column:name_pl"`
+	NameEN    string `gorm:"column:name_en"`
+	NameUK    string `gorm:"column:name_uk"`
+}
+
+func (seedCategory) TableName() string {
+	return "categories"
+}
+
+// seedCategories are the categories the clinic started with, so the codes programs
+// and prices had before the categories table keep pointing to a category.
+var seedCategories = []seedCategory{
+	{Code: "KS", Slug: "kosmetologia", SortOrder: 10, Name: "Kosmetologia", NamePL: "Kosmetologia", NameEN: "Cosmetology", NameUK: "Косметологія"},
+	{Code: "LS", Slug: "laseroterapia", SortOrder: 20, Name: "Laseroterapia", NamePL: "Laseroterapia", NameEN: "Laser therapy", NameUK: "Лазеротерапія"},
+	{Code: "KT", Slug: "kosmetyka", SortOrder: 30, Name: "Kosmetyka", NamePL: "Kosmetyka", NameEN: "Beauty care", NameUK: "Косметика"},
+	{Code: "ZE", Slug: "zabiegi-estetyczne", SortOrder: 40, Name: "Zabiegi estetyczne", NamePL: "Zabiegi estetyczne", NameEN: "Aesthetic treatments", NameUK: "Естетичні процедури"},
+	{Code: "TR", Slug: "trychologia", SortOrder: 50, Name: "Trychologia", NamePL: "Trychologia", NameEN: "Trichology", NameUK: "Трихологія"},
+	{Code: "PD", Slug: "podologia", SortOrder: 60, Name: "Podologia", NamePL: "Podologia", NameEN: "Podology", NameUK: "Подологія"},
+	{Code: "MS", Slug: "masaze", SortOrder: 70, Name: "Masaże", NamePL: "Masaże", NameEN: "Massages", NameUK: "Масажі"},
+}
+
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "seed_categories",
+		// Categories already in the database are left untouched, deleted ones included
+		Up: func(tx *gorm.DB) error {
+			for _, category := range seedCategories {
+				if err := tx.Unscoped().Where("code = ?", category.Code).FirstOrCreate(&category).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		// Categories may be in use and edited by now, so they are kept
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
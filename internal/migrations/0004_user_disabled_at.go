@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userDisabledAt is the users column added by this migration.
+type userDisabledAt struct {
+	DisabledAt *time.Time
+}
+
+func (userDisabledAt) TableName() string {
+	return "users"
+}
+
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "user_disabled_at",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&userDisabledAt{}, "DisabledAt")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&userDisabledAt{}, "DisabledAt")
+		},
+	})
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// auditEntry is the audit_entries table created by this migration.
+type auditEntry struct {
+	ID         uint       `gorm:"primaryKey"`
+	CreatedAt  time.Time  `gorm:"index"`
+	UserID     *uint      `gorm:"index"`
+	User       *auditUser `gorm:"constraint:OnDelete:SET NULL"`
+	UserName   string     `gorm:"size:100"`
+	Source     string     `gorm:"size:10"`
+	Action     string     `gorm:"size:10;index"`
+	EntityType string     `gorm:"size:50;index:idx_audit_entity"`
+	EntityID   uint       `gorm:"index:idx_audit_entity"`
+	Changes    string     `gorm:"type:text"`
+}
+
+func (auditEntry) TableName() string {
+	return "audit_entries"
+}
+
+// auditUser is the users table the entries point to.
+type auditUser struct {
+	ID uint `gorm:"primaryKey"`
+}
+
+func (auditUser) TableName() string {
+	return "users"
+}
+
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "audit_entries",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&auditEntry{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("audit_entries")
+		},
+	})
+}
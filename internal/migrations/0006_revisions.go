@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// revision is the revisions table created by this migration.
+type revision struct {
+	ID           uint          `gorm:"primaryKey"`
+	CreatedAt    time.Time     `gorm:"index"`
+	EntityType   string        `gorm:"size:50;index:idx_revision_entity"`
+	EntityID     uint          `gorm:"index:idx_revision_entity"`
+	UserID       *uint         `gorm:"index"`
+	User         *revisionUser `gorm:"constraint:OnDelete:SET NULL"`
+	UserName     string        `gorm:"size:100"`
+	Data         string        `gorm:"type:text"`
+	Translations string        `gorm:"type:text"`
+}
+
+func (revision) TableName() string {
+	return "revisions"
+}
+
+// revisionUser is the users table the revisions point to.
+type revisionUser struct {
+	ID uint `gorm:"primaryKey"`
+}
+
+func (revisionUser) TableName() string {
+	return "users"
+}
+
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "revisions",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&revision{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("revisions")
+		},
+	})
+}
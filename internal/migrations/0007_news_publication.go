@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// newsPublication is the news columns added by this migration.
+// Existing news get the published status, so they stay visible.
+type newsPublication struct {
+	Status      string `gorm:"size:20;default:published;index"`
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+}
+
+func (newsPublication) TableName() string {
+	return "news"
+}
+
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "news_publication",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"Status", "PublishAt", "UnpublishAt"} {
+				if err := tx.Migrator().AddColumn(&newsPublication{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateIndex(&newsPublication{}, "Status")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&newsPublication{}, "Status"); err != nil {
+				return err
+			}
+			for _, column := range []string{"UnpublishAt", "PublishAt", "Status"} {
+				if err := tx.Migrator().DropColumn(&newsPublication{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
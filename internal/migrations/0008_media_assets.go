@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// mediaAsset is the media_assets table created by this migration.
+type mediaAsset struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Path         string `gorm:"size:255;uniqueIndex"`
+	OriginalName string `gorm:"size:255;index"`
+	Width        int
+	Height       int
+	Size         int64
+	AltText      string     `gorm:"size:255"`
+	AltTextPL    string     `gorm:"size:255;column:alt_text_pl"`
+	AltTextEN    string     `gorm:"size:255;column:alt_text_en"`
+	AltTextUK    string     `gorm:"size:255;column:alt_text_uk"`
+	UserID       *uint      `gorm:"index"`
+	User         *mediaUser `gorm:"constraint:OnDelete:SET NULL"`
+}
+
+func (mediaAsset) TableName() string {
+	return "media_assets"
+}
+
+// mediaUser is the users table the assets point to.
+type mediaUser struct {
+	ID uint `gorm:"primaryKey"`
+}
+
+func (mediaUser) TableName() string {
+	return "users"
+}
+
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "media_assets",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&mediaAsset{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("media_assets")
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// mediaVariant is the media_variants table created by this migration.
+type mediaVariant struct {
+	ID           uint   `gorm:"primarykey"`
+	MediaAssetID uint   `gorm:"index"`
+	Path         string `gorm:"size:255;uniqueIndex"`
+	Format       string `gorm:"size:10"`
+	Width        int
+	Height       int
+	Size         int64
+}
+
+func (mediaVariant) TableName() string {
+	return "media_variants"
+}
+
+// mediaVariantOwner is the media_assets table, the variants are deleted with their asset.
+type mediaVariantOwner struct {
+	ID       uint           `gorm:"primarykey"`
+	Variants []mediaVariant `gorm:"foreignKey:MediaAssetID;constraint:OnDelete:CASCADE"`
+}
+
+func (mediaVariantOwner) TableName() string {
+	return "media_assets"
+}
+
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "media_variants",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&mediaVariantOwner{}, &mediaVariant{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("media_variants")
+		},
+	})
+}
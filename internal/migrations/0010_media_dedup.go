@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// mediaDedup is the media asset columns added by this migration.
+type mediaDedup struct {
+	Hash   string `gorm:"size:64;index"`
+	Pinned bool   `gorm:"not null;default:false"`
+}
+
+func (mediaDedup) TableName() string {
+	return "media_assets"
+}
+
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "media_dedup",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"Hash", "Pinned"} {
+				if err := tx.Migrator().AddColumn(&mediaDedup{}, column); err != nil {
+					return err
+				}
+			}
+			if err := tx.Migrator().CreateIndex(&mediaDedup{}, "Hash"); err != nil {
+				return err
+			}
+			// Existing images are kept until they are deleted from the library by hand
+			return tx.Model(&mediaDedup{}).Where("1 = 1").Update("pinned", true).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&mediaDedup{}, "Hash"); err != nil {
+				return err
+			}
+			for _, column := range []string{"Pinned", "Hash"} {
+				if err := tx.Migrator().DropColumn(&mediaDedup{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userLockout is the users columns added by this migration.
+type userLockout struct {
+	FailedLogins int `gorm:"not null;default:0"`
+	LockedUntil  *time.Time
+}
+
+func (userLockout) TableName() string {
+	return "users"
+}
+
+// loginAttempt is the login_attempts table created by this migration.
+type loginAttempt struct {
+	ID        uint              `gorm:"primaryKey"`
+	CreatedAt time.Time         `gorm:"index"`
+	UserName  string            `gorm:"size:100;index"`
+	UserID    *uint             `gorm:"index"`
+	User      *loginAttemptUser `gorm:"constraint:OnDelete:SET NULL"`
+	IP        string            `gorm:"size:45;index"`
+}
+
+func (loginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// loginAttemptUser is the users table the attempts point to.
+type loginAttemptUser struct {
+	ID uint `gorm:"primaryKey"`
+}
+
+func (loginAttemptUser) TableName() string {
+	return "users"
+}
+
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "login_protection",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"FailedLogins", "LockedUntil"} {
+				if err := tx.Migrator().AddColumn(&userLockout{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.AutoMigrate(&loginAttempt{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable("login_attempts"); err != nil {
+				return err
+			}
+			for _, column := range []string{"LockedUntil", "FailedLogins"} {
+				if err := tx.Migrator().DropColumn(&userLockout{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userTwoFactor is the users columns added by this migration.
+type userTwoFactor struct {
+	TOTPSecret    string `gorm:"size:64"`
+	TOTPLastStep  int64  `gorm:"not null;default:0"`
+	TOTPEnabledAt *time.Time
+}
+
+func (userTwoFactor) TableName() string {
+	return "users"
+}
+
+// recoveryCode is the recovery_codes table created by this migration.
+type recoveryCode struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint             `gorm:"index"`
+	User      recoveryCodeUser `gorm:"constraint:OnDelete:CASCADE"`
+	CodeHash  string           `gorm:"size:64"`
+	UsedAt    *time.Time
+}
+
+func (recoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// recoveryCodeUser is the users table the codes belong to.
+type recoveryCodeUser struct {
+	ID uint `gorm:"primaryKey"`
+}
+
+func (recoveryCodeUser) TableName() string {
+	return "users"
+}
+
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "two_factor",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"TOTPSecret", "TOTPLastStep", "TOTPEnabledAt"} {
+				if err := tx.Migrator().AddColumn(&userTwoFactor{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.AutoMigrate(&recoveryCode{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable("recovery_codes"); err != nil {
+				return err
+			}
+			for _, column := range []string{"TOTPEnabledAt", "TOTPLastStep", "TOTPSecret"} {
+				if err := tx.Migrator().DropColumn(&userTwoFactor{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
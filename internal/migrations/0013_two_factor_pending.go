@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// userTwoFactorPending is the users column added by this migration.
+type userTwoFactorPending struct {
+	TOTPPendingSecret string `gorm:"size:64"`
+}
+
+func (userTwoFactorPending) TableName() string {
+	return "users"
+}
+
+func init() {
+	register(Migration{
+		Version: 13,
+		Name:    "two_factor_pending",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&userTwoFactorPending{}, "TOTPPendingSecret")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&userTwoFactorPending{}, "TOTPPendingSecret")
+		},
+	})
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+const migrationTemplate = `package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(Migration{
+		Version: %d,
+		Name:    %q,
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`
+
+// Create writes an empty migration file with the next version number to dir,
+// e.g. internal/migrations/0004_add_news_slug.go, and returns its path.
+func Create(dir, name string) (string, error) {
+	name = strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", fmt.Errorf("migration name is required")
+	}
+	var version uint = 1
+	if len(registry) > 0 {
+		version = registry[len(registry)-1].Version + 1
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%04d_%s.go", version, name))
+	// O_EXCL keeps an existing file from being overwritten
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintf(file, migrationTemplate, version, name); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,242 @@
+// Package migrations keeps the versioned database migrations.
+//
+// Every migration is a numbered Go file in this package, e.g. 0002_search_indexes.go,
+// registering its Up and Down steps in init(). Applied versions are recorded in the
+// schema_migrations table, and a row in schema_migrations_lock keeps several
+// instances from migrating at the same time.
+// Migrations are run with: go run ./cmd/clinicctl migrate up
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration is a row of the schema_migrations table, one per applied migration.
+type SchemaMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// schemaMigrationLock is the single row of the schema_migrations_lock table.
+// LockedBy is empty while no migration runs.
+type schemaMigrationLock struct {
+	ID       uint `gorm:"primaryKey;autoIncrement:false"`
+	LockedBy string
+	LockedAt *time.Time
+}
+
+func (schemaMigrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// Lock timings, variables so the tests can wait less
+var (
+	// A lock older than this is left over by a crashed run and is taken over
+	staleLockAfter = 15 * time.Minute
+	lockWait       = 60 * time.Second
+	lockRetry      = time.Second
+)
+
+// ErrLocked is returned when another instance keeps the migration lock.
+var ErrLocked = errors.New("migrations are locked by another process")
+
+var registry []Migration
+
+// register adds a migration, it is called from the init function of each migration file.
+func register(migration Migration) {
+	for _, registered := range registry {
+		if registered.Version == migration.Version {
+			panic(fmt.Sprintf("migration %d is registered twice", migration.Version))
+		}
+	}
+	registry = append(registry, migration)
+	sort.Slice(registry, func(i, j int) bool {
+		return registry[i].Version < registry[j].Version
+	})
+}
+
+// All returns the registered migrations, oldest first.
+func All() []Migration {
+	return append([]Migration(nil), registry...)
+}
+
+// Status is a migration with the time it was applied, nil while pending.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies and rolls back migrations on a database.
+type Migrator struct {
+	db    *gorm.DB
+	owner string
+}
+
+// New returns a Migrator for the database.
+func New(db *gorm.DB) *Migrator {
+	host, _ := os.Hostname()
+	return &Migrator{db: db, owner: fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().UnixNano())}
+}
+
+// prepare creates the bookkeeping tables.
+func (m *Migrator) prepare() error {
+	if err := m.db.AutoMigrate(&SchemaMigration{}, &schemaMigrationLock{}); err != nil {
+		return err
+	}
+	// Concurrent instances may both try to insert the lock row
+	return m.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&schemaMigrationLock{ID: 1}).Error
+}
+
+// applied returns the applied migrations by version.
+func (m *Migrator) applied() (map[uint]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[uint]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status returns all migrations with the time they were applied.
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.prepare(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(registry))
+	for _, migration := range registry {
+		status := Status{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that are not applied yet.
+func (m *Migrator) Pending() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies up to steps pending migrations, oldest first. Zero steps applies all of them.
+func (m *Migrator) Up(steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func() error {
+		pending, err := m.Pending()
+		if err != nil {
+			return err
+		}
+		if steps > 0 && steps < len(pending) {
+			pending = pending[:steps]
+		}
+		for _, migration := range pending {
+			err := m.db.Transaction(func(tx *gorm.DB) error {
+				if err := migration.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back up to steps applied migrations, newest first.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func() error {
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := statuses[i].Migration
+			if statuses[i].AppliedAt == nil {
+				continue
+			}
+			err := m.db.Transaction(func(tx *gorm.DB) error {
+				if err := migration.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&SchemaMigration{}, migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rolling back %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// withLock runs fn while holding the migration lock, waiting for another run to finish.
+func (m *Migrator) withLock(fn func() error) error {
+	if err := m.prepare(); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(lockWait)
+	for {
+		now := time.Now()
+		result := m.db.Model(&schemaMigrationLock{}).
+			Where("id = ? AND (locked_by = ? OR locked_at < ?)", 1, "", now.Add(-staleLockAfter)).
+			Updates(map[string]any{"locked_by": m.owner, "locked_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			break
+		}
+		if now.After(deadline) {
+			return ErrLocked
+		}
+		time.Sleep(lockRetry)
+	}
+	defer m.db.Model(&schemaMigrationLock{}).
+		Where("id = ? AND locked_by = ?", 1, m.owner).
+		Updates(map[string]any{"locked_by": "", "locked_at": nil})
+	return fn()
+}
@@ -0,0 +1,227 @@
+package migrations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/database"
+	"gorm.io/gorm"
+)
+
+// newTestDB returns an empty in-memory SQLite database.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.DB_Connect(database.DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// appliedVersions returns the versions recorded in schema_migrations.
+func appliedVersions(t *testing.T, db *gorm.DB) []uint {
+	t.Helper()
+	var versions []uint
+	if err := db.Model(&SchemaMigration{}).Order("version").Pluck("version", &versions).Error; err != nil {
+		t.Fatal(err)
+	}
+	return versions
+}
+
+func TestRegistryIsNumberedInOrder(t *testing.T) {
+	for i, migration := range All() {
+		if migration.Version != uint(i+1) {
+			t.Fatalf("migration %d is %04d_%s, versions must follow each other", i+1, migration.Version, migration.Name)
+		}
+		if migration.Up == nil || migration.Down == nil {
+			t.Fatalf("migration %04d_%s needs both Up and Down", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestUpAppliesPendingMigrations(t *testing.T) {
+	db := newTestDB(t)
+	m := New(db)
+
+	done, err := m.Up(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 2 || len(appliedVersions(t, db)) != 2 {
+		t.Fatalf("applied %d migrations, recorded %v, want 2", len(done), appliedVersions(t, db))
+	}
+
+	done, err = m.Up(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != len(All())-2 {
+		t.Fatalf("applied %d migrations, want the %d left", len(done), len(All())-2)
+	}
+	pending, err := m.Pending()
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("got %d pending migrations, %v after applying all", len(pending), err)
+	}
+	for _, table := range []string{"programs", "users", "audit_entries", "media_variants", "login_attempts", "recovery_codes"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s is missing", table)
+		}
+	}
+
+	// Nothing is left to do
+	if done, err := m.Up(0); err != nil || len(done) != 0 {
+		t.Fatalf("applied %d migrations, %v on an up to date database", len(done), err)
+	}
+}
+
+func TestSeedCategoriesKeepsExistingOnes(t *testing.T) {
+	db := newTestDB(t)
+	m := New(db)
+	if _, err := m.Up(2); err != nil {
+		t.Fatal(err)
+	}
+	// Categories added before the seed, one of them deleted
+	edited := seedCategory{Code: "KS", Slug: "cosmetology", Name: "Cosmetology"}
+	deleted := seedCategory{Code: "MS", Slug: "masaze", Name: "Masaże"}
+	for _, category := range []*seedCategory{&edited, &deleted} {
+		if err := db.Create(category).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(1); err != nil {
+		t.Fatal(err)
+	}
+	var categories []seedCategory
+	if err := db.Unscoped().Order("code").Find(&categories).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != len(seedCategories) {
+		t.Fatalf("got %d categories, want %d", len(categories), len(seedCategories))
+	}
+	for _, category := range categories {
+		switch category.Code {
+		case "KS":
+			if category.Name != "Cosmetology" {
+				t.Errorf("the existing KS category was renamed to %q", category.Name)
+			}
+		case "MS":
+			if !category.DeletedAt.Valid {
+				t.Error("the deleted MS category was brought back")
+			}
+		}
+	}
+}
+
+func TestDownRollsBackNewestFirst(t *testing.T) {
+	db := newTestDB(t)
+	m := New(db)
+	if _, err := m.Up(0); err != nil {
+		t.Fatal(err)
+	}
+	all := All()
+	last := all[len(all)-1]
+
+	done, err := m.Down(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].Version != last.Version {
+		t.Fatalf("rolled back %v, want %04d_%s", done, last.Version, last.Name)
+	}
+	pending, err := m.Pending()
+	if err != nil || len(pending) != 1 || pending[0].Version != last.Version {
+		t.Fatalf("got pending %v, %v, want only %04d", pending, err, last.Version)
+	}
+
+	// Every migration rolls back, and applies again on the emptied database
+	if _, err := m.Down(len(all)); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, db); len(versions) != 0 {
+		t.Fatalf("versions %v are still recorded", versions)
+	}
+	for _, table := range []string{"programs", "users", "media_assets", "login_attempts"} {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("table %s is left over", table)
+		}
+	}
+	if _, err := m.Up(0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockKeepsOtherRunsOut(t *testing.T) {
+	db := newTestDB(t)
+	defer func(wait, retry time.Duration) { lockWait, lockRetry = wait, retry }(lockWait, lockRetry)
+	lockWait, lockRetry = 50*time.Millisecond, 10*time.Millisecond
+
+	// Another instance is migrating
+	if err := New(db).prepare(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := db.Model(&schemaMigrationLock{}).Where("id = ?", 1).
+		Updates(map[string]any{"locked_by": "other", "locked_at": now}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(db).Up(0); !errors.Is(err, ErrLocked) {
+		t.Fatalf("got %v, want ErrLocked", err)
+	}
+	if versions := appliedVersions(t, db); len(versions) != 0 {
+		t.Fatalf("versions %v were applied without the lock", versions)
+	}
+
+	// A lock left over by a crashed run is taken over, and released afterwards
+	if err := db.Model(&schemaMigrationLock{}).Where("id = ?", 1).
+		Update("locked_at", now.Add(-staleLockAfter-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(db).Up(0); err != nil {
+		t.Fatal(err)
+	}
+	var lock schemaMigrationLock
+	if err := db.First(&lock, 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	if lock.LockedBy != "" || lock.LockedAt != nil {
+		t.Fatalf("lock is still held by %q since %v", lock.LockedBy, lock.LockedAt)
+	}
+}
+
+func TestFailedMigrationIsNotRecorded(t *testing.T) {
+	db := newTestDB(t)
+	defer func(saved []Migration) { registry = saved }(registry)
+	failing := errors.New("broken migration")
+	registry = append(All(), Migration{
+		Version: uint(len(registry) + 1),
+		Name:    "broken",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE broken (id integer)").Error; err != nil {
+				return err
+			}
+			return failing
+		},
+		Down: func(tx *gorm.DB) error { return nil },
+	})
+
+	if _, err := New(db).Up(0); !errors.Is(err, failing) {
+		t.Fatalf("got %v, want the migration error", err)
+	}
+	if versions := appliedVersions(t, db); len(versions) != len(registry)-1 {
+		t.Fatalf("recorded %d versions, want all but the broken one", len(versions))
+	}
+	// Its changes are rolled back with it
+	if db.Migrator().HasTable("broken") {
+		t.Fatal("table of the failed migration is left over")
+	}
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIToken is a bearer token used by external clients to call the
+// mutating /api/v1 endpoints. Only the SHA-256 hash of the token is stored,
+// the plain value is shown once when the token is issued.
+type APIToken struct {
+	gorm.Model
+	Name      string `gorm:"size:100" form:"name"`
+	TokenHash string `gorm:"size:64;uniqueIndex"`
+	// Prefix keeps the first characters of the token so admins can recognise it
+	Prefix string `gorm:"size:12"`
+	// Scopes is a comma separated list, e.g. "programs:read,programs:write"
+	Scopes     string `gorm:"size:255"`
+	UserID     uint
+	User       User
+	LastUsedAt *time.Time
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+}
+
+// ScopeList returns the token scopes as a slice.
+func (t APIToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// HasScope reports whether the token grants the given scope.
+// A write scope also grants read access to the same resource.
+func (t APIToken) HasScope(scope string) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope {
+			return true
+		}
+		if strings.HasSuffix(scope, ":"+ScopeRead) && s == strings.TrimSuffix(scope, ScopeRead)+ScopeWrite {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether the token is neither revoked nor expired.
+func (t APIToken) IsActive() bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	if t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Appointment is a booking request made by a patient for a program.
+type Appointment struct {
+	gorm.Model
+	ProgramID    uint `gorm:"index"`
+	Program      Program
+	PatientName  string    `gorm:"size:150"`
+	PatientPhone string    `gorm:"size:30"`
+	PatientEmail string    `gorm:"size:255"`
+	RequestedAt  time.Time `gorm:"index"`
+	Status       string    `gorm:"size:20;index;default:pending"`
+	Notes        string    `gorm:"type:text"`
+}
+
+// CanTransition reports whether the appointment can be moved to the given status.
+func (a Appointment) CanTransition(to string) bool {
+	return CanTransitionAppointment(a.Status, to)
+}
@@ -0,0 +1,27 @@
+package models
+
+// Appointment status constants
+const (
+	AppointmentPending   string = "pending"
+	AppointmentConfirmed string = "confirmed"
+	AppointmentCancelled string = "cancelled"
+	AppointmentCompleted string = "completed"
+)
+
+var AllAppointmentStatuses = []string{AppointmentPending, AppointmentConfirmed, AppointmentCancelled, AppointmentCompleted}
+
+// appointmentTransitions lists the statuses an appointment may move to from a given status.
+var appointmentTransitions = map[string][]string{
+	AppointmentPending:   {AppointmentConfirmed, AppointmentCancelled},
+	AppointmentConfirmed: {AppointmentCompleted, AppointmentCancelled},
+}
+
+// CanTransitionAppointment reports whether an appointment can move from one status to another.
+func CanTransitionAppointment(from, to string) bool {
+	for _, status := range appointmentTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package models
+
+// Audit entry action constants
+const (
+	AuditCreate string = "create"
+	AuditUpdate string = "update"
+	AuditDelete string = "delete"
+	// A deleted record brought back from the recycle bin
+	AuditRestore string = "restore"
+	// A deleted record removed from the recycle bin for good
+	AuditPurge string = "purge"
+)
+
+var AllAuditActions = []string{AuditCreate, AuditUpdate, AuditDelete, AuditRestore, AuditPurge}
+
+// Audit entry source constants, telling where a change came from
+const (
+	AuditSourceAdmin  string = "admin"
+	AuditSourceAPI    string = "api"
+	AuditSourcePublic string = "public"
+)
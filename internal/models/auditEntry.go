@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// AuditEntry records a single change made in the admin panel or through the API.
+// Entries are never edited or deleted, so there is no gorm.Model and no soft delete.
+type AuditEntry struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index"`
+	// UserID is empty for changes of anonymous visitors, e.g. booked appointments.
+	// It is cleared when the user is deleted, UserName keeps who it was.
+	UserID   *uint  `gorm:"index"`
+	User     *User  `gorm:"constraint:OnDelete:SET NULL"`
+	UserName string `gorm:"size:100"`
+	// Source is admin, api or public
+	Source     string `gorm:"size:10"`
+	Action     string `gorm:"size:10;index"`
+	EntityType string `gorm:"size:50;index:idx_audit_entity"`
+	EntityID   uint   `gorm:"index:idx_audit_entity"`
+	// Changes is a JSON object of the changed fields: {"Price": {"before": 100, "after": 120}}
+	Changes string `gorm:"type:text"`
+}
+
+// AuditChange is the before and after value of a single field.
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditChangeLine is a change prepared for display.
+type AuditChangeLine struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// ChangeList returns the changed fields sorted by name, with the values formatted for display.
+func (e AuditEntry) ChangeList() []AuditChangeLine {
+	var changes map[string]AuditChange
+	if err := json.Unmarshal([]byte(e.Changes), &changes); err != nil {
+		return nil
+	}
+	lines := make([]AuditChangeLine, 0, len(changes))
+	for field, change := range changes {
+		lines = append(lines, AuditChangeLine{Field: field, Before: DisplayValue(change.Before), After: DisplayValue(change.After)})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].Field < lines[j].Field
+	})
+	return lines
+}
+
+// DisplayValue formats a decoded JSON value, showing a missing value as an empty string.
+func DisplayValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
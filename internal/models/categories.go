@@ -1,22 +0,0 @@
-package models
-
-// Category constants
-const (
-	Kosmetologia      string = "KS"
-	Laseroterapia     string = "LS"
-	Kosmetyka         string = "KT"
-	ZabiegiEstetyczne string = "ZE"
-	Trychologia       string = "TR"
-	Podologia         string = "PD"
-	Masaze            string = "MS"
-)
-
-var AllCategories = []string{
-	Kosmetologia,
-	Laseroterapia,
-	Kosmetyka,
-	ZabiegiEstetyczne,
-	Trychologia,
-	Podologia,
-	Masaze,
-}
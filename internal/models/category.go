@@ -0,0 +1,25 @@
+package models
+
+import "gorm.io/gorm"
+
+// Category groups programs and prices into service lines.
+// Programs and prices reference a category by its two letter Code,
+// translation fields follow the django-modeltranslation layout.
+type Category struct {
+	gorm.Model
+	Code      string `gorm:"size:2;uniqueIndex" form:"code"`
+	Slug      string `gorm:"size:100;uniqueIndex" form:"slug"`
+	SortOrder int    `form:"sort_order"`
+
+	// Original and default language name
+	Name string `gorm:"size:150" form:"name" translate:"name"`
+	// Translation for Polish
+	NamePL string `gorm:"size:150;column:name_pl" form:"name_pl"`
+	// Translation for English
+	NameEN string `gorm:"size:150;column:name_en" form:"name_en"`
+	// Translation for Ukrainian
+	NameUK string `gorm:"size:150;column:name_uk" form:"name_uk"`
+
+	// Icon image URL
+	Icon string `gorm:"type:text"`
+}
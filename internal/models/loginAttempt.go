@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// LoginAttempt records a failed login to the admin panel. Like the audit log
+// the attempts are never edited, so there is no gorm.Model and no soft delete.
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index"`
+	// UserName is the name that was given, it does not have to belong to a user
+	UserName string `gorm:"size:100;index"`
+	// UserID is empty when no user has the given name
+	UserID *uint  `gorm:"index"`
+	User   *User  `gorm:"constraint:OnDelete:SET NULL"`
+	IP     string `gorm:"size:45;index"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// MediaAsset is an uploaded image kept in the media library, so it can be reused.
+// Assets are deleted together with their file, so there is no soft delete.
+type MediaAsset struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Public path of the file, e.g. "/uploads/3a7bd3e2360a3d29eea436fcfb7e44c7.jpg"
+	Path string `gorm:"size:255;uniqueIndex"`
+	// SHA-256 of the uploaded file, finds the uploads of an image already in the library.
+	// Empty for the images uploaded before hashing
+	Hash string `gorm:"size:64;index" form:"-"`
+	// Uploaded to the library itself, so kept when no record shows it anymore.
+	// Images uploaded with a record are removed once no record shows them
+	Pinned bool `gorm:"not null;default:false" form:"-"`
+	// File name on the uploader's computer
+	OriginalName string `gorm:"size:255;index"`
+	Width        int
+	Height       int
+	// File size in bytes
+	Size int64
+
+	// Alternative text of the image, in the default language
+	AltText string `gorm:"size:255" form:"alt_text" translate:"alt_text"`
+	// Translation for Polish
+	AltTextPL string `gorm:"size:255;column:alt_text_pl" form:"alt_text_pl"`
+	// Translation for English
+	AltTextEN string `gorm:"size:255;column:alt_text_en" form:"alt_text_en"`
+	// Translation for Ukrainian
+	AltTextUK string `gorm:"size:255;column:alt_text_uk" form:"alt_text_uk"`
+
+	// The user who uploaded the image, kept empty when that user is deleted
+	UserID *uint `gorm:"index"`
+	User   *User `gorm:"constraint:OnDelete:SET NULL"`
+
+	// Resized copies of the image, the main file included
+	Variants []MediaVariant `gorm:"constraint:OnDelete:CASCADE" form:"-"`
+}
+
+// Thumbnail returns the path of the smallest variant in the original format,
+// or the main file when the variants are not loaded.
+func (m MediaAsset) Thumbnail() string {
+	thumbnail := MediaVariant{Path: m.Path, Width: m.Width}
+	for _, variant := range m.Variants {
+		if variant.Format != "webp" && variant.Width < thumbnail.Width {
+			thumbnail = variant
+		}
+	}
+	return thumbnail.Path
+}
+
+// SizeLabel returns the file size in a readable form, e.g. "120 KB".
+func (m MediaAsset) SizeLabel() string {
+	switch {
+	case m.Size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(m.Size)/(1<<20))
+	case m.Size >= 1<<10:
+		return fmt.Sprintf("%d KB", m.Size/(1<<10))
+	}
+	return fmt.Sprintf("%d B", m.Size)
+}
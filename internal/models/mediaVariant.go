@@ -0,0 +1,21 @@
+package models
+
+// MediaVariant is a resized copy of a media asset, in the original format or in WebP,
+// used to build the srcset of an image.
+type MediaVariant struct {
+	ID           uint `gorm:"primarykey"`
+	MediaAssetID uint `gorm:"index"`
+	// Public path of the file, e.g. "/uploads/1700000000photo-400w.webp"
+	Path string `gorm:"size:255;uniqueIndex"`
+	// Image format, e.g. "jpeg", "png" or "webp"
+	Format string `gorm:"size:10"`
+	Width  int
+	Height int
+	// File size in bytes
+	Size int64
+}
+
+// MimeType returns the content type of the variant, e.g. "image/webp".
+func (v MediaVariant) MimeType() string {
+	return "image/" + v.Format
+}
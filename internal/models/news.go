@@ -10,10 +10,10 @@ import (
 
 type News struct {
 	gorm.Model
-	Title       string `gorm:"size:250;unique" form:"title"`
-	Header      string `gorm:"type:text" form:"header"`
-	Description string `gorm:"type:text" form:"description"`
-	Features    string `gorm:"type:text" form:"features"`
+	Title       string `gorm:"size:250;unique" form:"title" translate:"title"`
+	Header      string `gorm:"type:text" form:"header" translate:"header"`
+	Description string `gorm:"type:text" form:"description" translate:"description"`
+	Features    string `gorm:"type:text" form:"features" translate:"features"`
 	PostedOn    time.Time
 	// Translation fields for Polish language
 	TitlePL       string `gorm:"size:250;column:title_pl" form:"title_pl"`
@@ -33,4 +33,9 @@ type News struct {
 	// Images URLs
 	ImageLeft  string `gorm:"type:text"`
 	ImageRight string `gorm:"type:text"`
+	// Publication status, one of AllNewsStatuses. Only published news are shown on the site.
+	Status string `gorm:"size:20;default:published;index" form:"status"`
+	// Scheduled news are published at PublishAt, published news are archived at UnpublishAt
+	PublishAt   *time.Time `form:"-"`
+	UnpublishAt *time.Time `form:"-"`
 }
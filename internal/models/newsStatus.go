@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// News status constants
+const (
+	NewsDraft     string = "draft"
+	NewsScheduled string = "scheduled"
+	NewsPublished string = "published"
+	NewsArchived  string = "archived"
+)
+
+var AllNewsStatuses = []string{NewsDraft, NewsScheduled, NewsPublished, NewsArchived}
+
+// IsPublic reports whether the news is shown on the site at the given time.
+// A scheduled news whose publication time has passed counts as published,
+// even before the scheduler has updated its status.
+func (n News) IsPublic(now time.Time) bool {
+	published := n.Status == NewsPublished ||
+		n.Status == NewsScheduled && n.PublishAt != nil && !n.PublishAt.After(now)
+	return published && (n.UnpublishAt == nil || n.UnpublishAt.After(now))
+}
+
+// CheckSchedule validates the status and the publication times of a news.
+func (n News) CheckSchedule() error {
+	known := false
+	for _, status := range AllNewsStatuses {
+		known = known || n.Status == status
+	}
+	if !known {
+		return errors.New("unknown news status")
+	}
+	if n.Status == NewsScheduled && n.PublishAt == nil {
+		return errors.New("scheduled news need a publication time")
+	}
+	if n.PublishAt != nil && n.UnpublishAt != nil && !n.UnpublishAt.After(*n.PublishAt) {
+		return errors.New("unpublish time must be after the publication time")
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OpeningHours stores the clinic opening hours for one day of the week.
+// Times are kept as "HH:MM" strings in the clinic local time.
+type OpeningHours struct {
+	gorm.Model
+	Weekday  int    `gorm:"uniqueIndex"` // 0 = Sunday, like time.Weekday
+	OpensAt  string `gorm:"size:5" form:"opens_at"`
+	ClosesAt string `gorm:"size:5" form:"closes_at"`
+	Closed   bool   `form:"closed"`
+}
+
+// WeekdayName returns the English name of the day.
+func (h OpeningHours) WeekdayName() string {
+	return time.Weekday(h.Weekday).String()
+}
@@ -8,7 +8,7 @@ import "gorm.io/gorm"
 type Price struct {
 	gorm.Model
 
-	ItemName string  `gorm:"size:150;unique" form:"itemName"`
+	ItemName string  `gorm:"size:150;unique" form:"itemName" translate:"item_name"`
 	Price    float32 `form:"price"`
 	// Translation for Polish
 	ItemNamePL string `gorm:"size:150;column:item_name_pl" form:"itemName_pl"`
@@ -17,4 +17,22 @@ type Price struct {
 	// Translation for Ukrainian
 	ItemNameUK string `gorm:"size:150;column:item_name_uk" form:"itemName_uk"`
 	Category   string `gorm:"size:2" form:"category"`
+
+	// Optional link to the program the price belongs to
+	ProgramID *uint    `gorm:"index" form:"-"`
+	Program   *Program `form:"-"`
+	// Variant of the price, one of AllPriceVariants
+	Variant string `gorm:"size:20;default:single" form:"variant"`
+	// Number of sessions included, used by package prices
+	Sessions int `gorm:"default:1" form:"sessions"`
+	// Treated area, used by area prices, e.g. "small", "face"
+	Area string `gorm:"size:50" form:"area"`
+}
+
+// LinkedProgramID returns the ID of the linked program, or 0 when the price is not linked.
+func (p Price) LinkedProgramID() uint {
+	if p.ProgramID == nil {
+		return 0
+	}
+	return *p.ProgramID
 }
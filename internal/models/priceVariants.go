@@ -0,0 +1,10 @@
+package models
+
+// Price variant constants
+const (
+	PriceSingle  string = "single"  // one session
+	PricePackage string = "package" // several sessions bought together
+	PriceArea    string = "area"    // price depends on the treated area size
+)
+
+var AllPriceVariants = []string{PriceSingle, PricePackage, PriceArea}
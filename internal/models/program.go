@@ -10,9 +10,9 @@ type Program struct {
 	gorm.Model // This automatically includes ID, CreatedAt, UpdatedAt, DeletedAt
 
 	// Original and defaulf language fields
-	Title       string `gorm:"size:250;unique" form:"title"`
-	Description string `gorm:"type:text" form:"description"`
-	Results     string `gorm:"type:text" form:"results"`
+	Title       string `gorm:"size:250;unique" form:"title" translate:"title"`
+	Description string `gorm:"type:text" form:"description" translate:"description"`
+	Results     string `gorm:"type:text" form:"results" translate:"results"`
 
 	// Translation fields for Polish language
 	TitlePL       string `gorm:"size:250;column:title_pl" form:"title_pl"`
@@ -30,4 +30,9 @@ type Program struct {
 	ResultsUK     string `gorm:"type:text;column:results_uk" form:"results_uk"`
 
 	Category string `gorm:"size:2" form:"category"`
+	// Duration of a single session in minutes, used to compute booking slots
+	Duration int `gorm:"default:60" form:"duration"`
+
+	// Price variants of the program
+	Prices []Price `form:"-"`
 }
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RecoveryCode logs a user in once without the authenticator app, e.g. after
+// losing the phone. Only the SHA-256 hash of the code is stored, the plain
+// codes are shown once when two-factor login is enabled.
+type RecoveryCode struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"index"`
+	User      User   `gorm:"constraint:OnDelete:CASCADE"`
+	CodeHash  string `gorm:"size:64"`
+	UsedAt    *time.Time
+}
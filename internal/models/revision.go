@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Revision is a saved version of a program, price or news item, taken before it was changed.
+type Revision struct {
+	ID         uint      `gorm:"primaryKey"`
+	CreatedAt  time.Time `gorm:"index"`
+	EntityType string    `gorm:"size:50;index:idx_revision_entity"`
+	EntityID   uint      `gorm:"index:idx_revision_entity"`
+	// The user who made the change that replaced this version
+	UserID   *uint  `gorm:"index"`
+	User     *User  `gorm:"constraint:OnDelete:SET NULL"`
+	UserName string `gorm:"size:100"`
+	// Data is the record as JSON, without its ID, timestamps and related records
+	Data string `gorm:"type:text"`
+	// Translations are the texts of the languages kept in the translations table, as JSON
+	Translations string `gorm:"type:text"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScheduleException marks days when the clinic or a specialist does not work,
+// e.g. public holidays or vacations. Without a specialist it applies to the whole clinic.
+type ScheduleException struct {
+	gorm.Model
+	SpecialistID *uint `gorm:"index"`
+	Specialist   *Specialist
+	StartsOn     time.Time `gorm:"type:date"`
+	EndsOn       time.Time `gorm:"type:date"`
+	Reason       string    `gorm:"size:250"`
+}
+
+// Covers reports whether the exception includes the given day.
+func (e ScheduleException) Covers(day time.Time) bool {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	starts := time.Date(e.StartsOn.Year(), e.StartsOn.Month(), e.StartsOn.Day(), 0, 0, 0, 0, time.UTC)
+	ends := time.Date(e.EndsOn.Year(), e.EndsOn.Month(), e.EndsOn.Day(), 0, 0, 0, 0, time.UTC)
+	return !day.Before(starts) && !day.After(ends)
+}
@@ -0,0 +1,26 @@
+package models
+
+import "gorm.io/gorm"
+
+// Specialist is a member of the clinic staff performing one or more programs.
+// Translation fields follow the same django-modeltranslation layout as Program.
+type Specialist struct {
+	gorm.Model
+
+	// Original and default language fields
+	Name           string `gorm:"size:150" form:"name"`
+	Bio            string `gorm:"type:text" form:"bio" translate:"bio"`
+	Qualifications string `gorm:"type:text" form:"qualifications"`
+
+	// Translation fields for Polish language
+	BioPL string `gorm:"type:text;column:bio_pl" form:"bio_pl"`
+	// Translation fields for English language
+	BioEN string `gorm:"type:text;column:bio_en" form:"bio_en"`
+	// Translation fields for Ukrainian language
+	BioUK string `gorm:"type:text;column:bio_uk" form:"bio_uk"`
+
+	// Photo URL
+	Photo string `gorm:"type:text"`
+
+	Programs []Program `gorm:"many2many:specialist_programs;" form:"-"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SpecialistSchedule is one working shift of a specialist on a day of the week.
+// A specialist may have several shifts on the same day.
+type SpecialistSchedule struct {
+	gorm.Model
+	SpecialistID uint `gorm:"index"`
+	Specialist   Specialist
+	Weekday      int    // 0 = Sunday, like time.Weekday
+	StartsAt     string `gorm:"size:5"`
+	EndsAt       string `gorm:"size:5"`
+}
+
+// WeekdayName returns the English name of the day.
+func (s SpecialistSchedule) WeekdayName() string {
+	return time.Weekday(s.Weekday).String()
+}
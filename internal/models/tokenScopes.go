@@ -0,0 +1,32 @@
+package models
+
+// API token access levels
+const (
+	ScopeRead  string = "read"
+	ScopeWrite string = "write"
+)
+
+// API resources that can be protected by a token scope
+const (
+	ResourcePrograms    string = "programs"
+	ResourcePrices      string = "prices"
+	ResourceNews        string = "news"
+	ResourceSpecialists string = "specialists"
+	ResourceCategories  string = "categories"
+)
+
+var AllTokenResources = []string{ResourcePrograms, ResourcePrices, ResourceNews, ResourceSpecialists, ResourceCategories}
+
+// TokenScope builds a scope name such as "prices:write".
+func TokenScope(resource, access string) string {
+	return resource + ":" + access
+}
+
+// AllTokenScopes lists every scope that can be granted to a token.
+func AllTokenScopes() []string {
+	scopes := make([]string, 0, len(AllTokenResources)*2)
+	for _, resource := range AllTokenResources {
+		scopes = append(scopes, TokenScope(resource, ScopeRead), TokenScope(resource, ScopeWrite))
+	}
+	return scopes
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Translation stores one translated field of a record for a language that has no
+// dedicated django-modeltranslation column, e.g. the German title of a program.
+type Translation struct {
+	ID         uint   `gorm:"primarykey"`
+	EntityType string `gorm:"size:50;uniqueIndex:idx_translation_key"`
+	EntityID   uint   `gorm:"uniqueIndex:idx_translation_key"`
+	Language   string `gorm:"size:10;uniqueIndex:idx_translation_key"`
+	Field      string `gorm:"size:50;uniqueIndex:idx_translation_key"`
+	Value      string `gorm:"type:text"`
+	UpdatedAt  time.Time
+}
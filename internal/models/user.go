@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
@@ -8,4 +12,32 @@ type User struct {
 	PasswordHash string `gorm:"size:255"`
 	Email        string `gorm:"size:255;unique"`
 	Role         string `gorm:"size:50"`
+	// Disabled users cannot log in and their API tokens stop working
+	DisabledAt *time.Time
+	// Wrong passwords given in a row, reset by a successful login
+	FailedLogins int `gorm:"not null;default:0"`
+	// The account cannot log in until then, after too many wrong passwords
+	LockedUntil *time.Time
+	// Base32 secret of the authenticator app, kept while two-factor login is enabled
+	TOTPSecret string `gorm:"size:64"`
+	// Time step of the last accepted code, so a code can not be used twice
+	TOTPLastStep  int64 `gorm:"not null;default:0"`
+	TOTPEnabledAt *time.Time
+	// Secret of the key being enrolled, until a code of it confirms it
+	TOTPPendingSecret string `gorm:"size:64"`
+}
+
+// IsDisabled reports whether the user account has been disabled.
+func (u User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// IsLocked reports whether the user account is locked out at the given time.
+func (u User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// HasTwoFactor reports whether the user logs in with a code of an authenticator app.
+func (u User) HasTwoFactor() bool {
+	return u.TOTPEnabledAt != nil
 }
@@ -0,0 +1,25 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"gorm.io/gorm"
+)
+
+// PurgeLoginAttempts returns the job deleting the failed logins older than the
+// given number of days. Only those of the last minutes slow down a login.
+func PurgeLoginAttempts(days int) Job {
+	return Job{
+		Name:  "purge login attempts",
+		Every: time.Hour,
+		Run: func(db *gorm.DB, now time.Time) error {
+			purged := db.Where("created_at < ?", now.AddDate(0, 0, -days)).Delete(&models.LoginAttempt{})
+			if purged.RowsAffected > 0 {
+				log.Printf("Purged %d failed logins older than %d days", purged.RowsAffected, days)
+			}
+			return purged.Error
+		},
+	}
+}
@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"gorm.io/gorm"
+)
+
+// PublishNews publishes the scheduled news whose publication time has come
+// and archives the published news whose unpublish time has passed.
+var PublishNews = Job{
+	Name:  "publish news",
+	Every: time.Minute,
+	Run:   publishNews,
+}
+
+func publishNews(db *gorm.DB, now time.Time) error {
+	published := db.Model(&models.News{}).
+		Where("status = ? AND publish_at <= ?", models.NewsScheduled, now).
+		Update("status", models.NewsPublished)
+	if published.Error != nil {
+		return published.Error
+	}
+	archived := db.Model(&models.News{}).
+		Where("status = ? AND unpublish_at <= ?", models.NewsPublished, now).
+		Update("status", models.NewsArchived)
+	if archived.Error != nil {
+		return archived.Error
+	}
+	if published.RowsAffected > 0 || archived.RowsAffected > 0 {
+		log.Printf("Published %d and archived %d news", published.RowsAffected, archived.RowsAffected)
+	}
+	return nil
+}
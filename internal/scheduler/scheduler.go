@@ -0,0 +1,44 @@
+// Package scheduler runs the periodic background jobs of the server.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job is a task run periodically in the background.
+type Job struct {
+	Name string
+	// Time between two runs
+	Every time.Duration
+	// Run does the work, now is the time of the run
+	Run func(db *gorm.DB, now time.Time) error
+}
+
+// Start runs every job in its own goroutine until the context is cancelled.
+// A job runs once right away and then every job.Every. Errors are logged
+// and the job is tried again on its next run.
+func Start(ctx context.Context, db *gorm.DB, jobs ...Job) {
+	for _, job := range jobs {
+		go run(ctx, db, job)
+	}
+}
+
+// run is the loop of a single job.
+func run(ctx context.Context, db *gorm.DB, job Job) {
+	ticker := time.NewTicker(job.Every)
+	defer ticker.Stop()
+	for {
+		if err := job.Run(db.WithContext(ctx), time.Now()); err != nil {
+			log.Printf("Job %s failed: %s", job.Name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
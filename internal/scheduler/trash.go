@@ -0,0 +1,25 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/trash"
+	"gorm.io/gorm"
+)
+
+// PurgeTrash returns the job permanently deleting the content that has been
+// in the trash for more than the given number of days.
+func PurgeTrash(days int) Job {
+	return Job{
+		Name:  "purge trash",
+		Every: time.Hour,
+		Run: func(db *gorm.DB, now time.Time) error {
+			purged, err := trash.PurgeOlderThan(db, now.AddDate(0, 0, -days))
+			if purged > 0 {
+				log.Printf("Purged %d items deleted more than %d days ago", purged, days)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Local keeps the uploads in a directory of the server. It only suits a single
+// instance, unless the directory is shared between them.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocal returns a backend keeping the files in dir, served under baseURL, e.g. "/uploads".
+func NewLocal(dir, baseURL string) *Local {
+	return &Local{dir: dir, baseURL: baseURL}
+}
+
+// Put writes a file through a temporary one, so readers never see it half written.
+func (l *Local) Put(name string, content io.Reader, size int64, contentType string) error {
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(l.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// Temporary files are private, uploads are served to everyone
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(l.dir, name))
+}
+
+// Open reads a file of the uploads directory.
+func (l *Local) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(l.dir, name))
+}
+
+// Delete removes a file of the uploads directory.
+func (l *Local) Delete(name string) error {
+	if err := os.Remove(filepath.Join(l.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List returns the files of the uploads directory. A missing directory has no files.
+func (l *Local) List() ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && entry.Name()[0] != '.' {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// URL returns the address of a file under the base URL.
+func (l *Local) URL(name string) string {
+	return publicURL(l.baseURL, name)
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options configure a bucket of Amazon S3 or of a compatible service such as MinIO.
+type S3Options struct {
+	// Host and port of the service, e.g. "s3.eu-central-1.amazonaws.com" or "localhost:9000"
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Use HTTPS to reach the service
+	UseSSL bool
+	// Public address of the bucket, e.g. a CDN. Defaults to the bucket on the endpoint
+	PublicURL string
+}
+
+// S3 keeps the uploads in an S3 compatible bucket, shared by all instances.
+type S3 struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string
+}
+
+// NewS3 connects to a bucket, making sure it exists.
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required")
+	}
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure: opts.UseSSL,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	exists, err := client.BucketExists(context.Background(), opts.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach bucket %s: %w", opts.Bucket, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %s does not exist", opts.Bucket)
+	}
+	baseURL := opts.PublicURL
+	if baseURL == "" {
+		baseURL = client.EndpointURL().String() + "/" + opts.Bucket
+	}
+	return &S3{client: client, bucket: opts.Bucket, baseURL: baseURL}, nil
+}
+
+// Put uploads a file to the bucket.
+func (s *S3) Put(name string, content io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(context.Background(), s.bucket, name, content, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+// Open downloads a file of the bucket.
+func (s *S3) Open(name string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(context.Background(), s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// The object is only requested on the first read, which is when a missing one shows
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+		}
+		return nil, err
+	}
+	return object, nil
+}
+
+// Delete removes a file of the bucket. S3 does not report missing files.
+func (s *S3) Delete(name string) error {
+	return s.client.RemoveObject(context.Background(), s.bucket, name, minio.RemoveObjectOptions{})
+}
+
+// List returns the files of the bucket.
+func (s *S3) List() ([]string, error) {
+	var names []string
+	for object := range s.client.ListObjects(context.Background(), s.bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		names = append(names, object.Key)
+	}
+	return names, nil
+}
+
+// URL returns the address of a file under the public address of the bucket.
+func (s *S3) URL(name string) string {
+	return publicURL(s.baseURL, name)
+}
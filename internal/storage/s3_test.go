@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+)
+
+// newTestS3 starts an S3 stand-in with an empty bucket and connects to it.
+func newTestS3(t *testing.T, bucket string) (*S3, S3Options) {
+	t.Helper()
+	backend := s3mem.New()
+	if err := backend.CreateBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+	opts := S3Options{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    bucket,
+		AccessKey: "access",
+		SecretKey: "secret",
+	}
+	s3, err := NewS3(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s3, opts
+}
+
+func TestS3PutOpenDelete(t *testing.T) {
+	s3, _ := newTestS3(t, "uploads")
+
+	if err := s3.Put("photo.jpg", strings.NewReader("image"), 5, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	file, err := s3.Open("photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || string(content) != "image" {
+		t.Fatalf("got %q, %v", content, err)
+	}
+
+	names, err := s3.List()
+	if err != nil || !slices.Equal(names, []string{"photo.jpg"}) {
+		t.Fatalf("got files %v, %v", names, err)
+	}
+
+	if err := s3.Delete("photo.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	// Deleting a missing file is not an error
+	if err := s3.Delete("photo.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s3.Open("photo.jpg"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("got %v, want fs.ErrNotExist for a deleted file", err)
+	}
+}
+
+func TestS3URL(t *testing.T) {
+	s3, opts := newTestS3(t, "uploads")
+	if got, want := s3.URL("my photo.jpg"), "http://"+opts.Endpoint+"/uploads/my%20photo.jpg"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	opts.PublicURL = "https://cdn.example.com/"
+	cdn, err := NewS3(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cdn.URL("photo.jpg"), "https://cdn.example.com/photo.jpg"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestNewS3RequiresBucket(t *testing.T) {
+	_, opts := newTestS3(t, "uploads")
+	opts.Bucket = "missing"
+	if _, err := NewS3(opts); err == nil {
+		t.Fatal("connected to a missing bucket")
+	}
+	opts.Bucket = ""
+	if _, err := NewS3(opts); err == nil {
+		t.Fatal("connected without a bucket")
+	}
+}
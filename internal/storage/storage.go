@@ -0,0 +1,101 @@
+// Package storage keeps the uploaded files, on the local disk or in an S3 compatible bucket.
+//
+// Records refer to an upload by its path, e.g. "/uploads/1700000000photo.jpg", whatever
+// backend keeps the file. URL turns the path into the address shown to the browser.
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/config"
+)
+
+// pathPrefix starts the paths of the uploads stored in the records.
+const pathPrefix = "/uploads/"
+
+// Storage is a backend keeping the uploaded files, addressed by their names,
+// e.g. "1700000000photo.jpg".
+type Storage interface {
+	// Put writes a file, replacing an existing one with the same name
+	Put(name string, content io.Reader, size int64, contentType string) error
+	// Open reads a file. A missing file gives an error matching fs.ErrNotExist
+	Open(name string) (io.ReadCloser, error)
+	// Delete removes a file. A missing file is not an error
+	Delete(name string) error
+	// List returns the names of all files
+	List() ([]string, error)
+	// URL returns the public address of a file
+	URL(name string) string
+}
+
+var (
+	mu      sync.RWMutex
+	current Storage = NewLocal("uploads", "/uploads")
+)
+
+// Configure sets the backend keeping the uploads, the local uploads directory by default.
+func Configure(backend Storage) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = backend
+}
+
+// Current returns the backend keeping the uploads.
+func Current() Storage {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// New returns the backend of the given name, "local" or "s3", set up from the config.
+func New(cfg config.Config, backend string) (Storage, error) {
+	switch backend {
+	case "local":
+		return NewLocal(cfg.UploadsDir, cfg.UploadsURL), nil
+	case "s3":
+		return NewS3(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+			PublicURL: cfg.S3PublicURL,
+		})
+	}
+	return nil, fmt.Errorf("unknown storage backend %q, use local or s3", backend)
+}
+
+// Path returns the path of an upload kept in the records, given its name.
+func Path(name string) string {
+	return pathPrefix + name
+}
+
+// Name returns the name of an upload, given its path such as "/uploads/1700000000photo.jpg".
+func Name(path string) (string, error) {
+	name, ok := strings.CutPrefix(path, pathPrefix)
+	if !ok || name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%s is not an upload", path)
+	}
+	return name, nil
+}
+
+// URL returns the public address of an upload, given its path.
+// Other paths and addresses are returned as they are.
+func URL(path string) string {
+	name, err := Name(path)
+	if err != nil {
+		return path
+	}
+	return Current().URL(name)
+}
+
+// publicURL joins the public address prefix of a backend and the name of a file.
+// Names keep the original name of the upload, so they may contain spaces.
+func publicURL(prefix, name string) string {
+	return strings.TrimSuffix(prefix, "/") + "/" + url.PathEscape(name)
+}
@@ -0,0 +1,61 @@
+package translation
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Negotiate picks the enabled language that best matches an Accept-Language header,
+// e.g. "uk-UA,uk;q=0.9,en;q=0.8". Region subtags are ignored, so "en-GB" matches "en".
+// The default language is returned when nothing matches.
+func Negotiate(header string) string {
+	type preference struct {
+		lang    string
+		quality float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if tag == "" || quality <= 0 {
+			continue
+		}
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		preferences = append(preferences, preference{lang: lang, quality: quality})
+	}
+	// The order of the header breaks ties between equal qualities
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+	for _, p := range preferences {
+		if p.lang == "*" {
+			return Default()
+		}
+		if Enabled(p.lang) {
+			return p.lang
+		}
+	}
+	return Default()
+}
+
+// Localized returns the fields in one language. Empty values fall back to the default language.
+func (v Values) Localized(lang string) map[string]string {
+	localized := make(map[string]string)
+	for field, value := range v[Default()] {
+		localized[field] = value
+	}
+	for field, value := range v[lang] {
+		if value != "" {
+			localized[field] = value
+		}
+	}
+	return localized
+}
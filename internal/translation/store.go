@@ -0,0 +1,74 @@
+package translation
+
+import (
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Load returns all translations of a model: the translation columns
+// merged with the rows of the translations table.
+func Load(db *gorm.DB, model any) (Values, error) {
+	values := Extract(model)
+	stored, err := LoadMany(db, EntityType(model), []uint{EntityID(model)})
+	if err != nil {
+		return nil, err
+	}
+	values.Merge(stored[EntityID(model)])
+	return values, nil
+}
+
+// LoadMany returns the rows of the translations table for several records of one type.
+// No query is made when only languages with translation columns are enabled.
+func LoadMany(db *gorm.DB, entityType string, ids []uint) (map[uint]Values, error) {
+	result := make(map[uint]Values, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	var rows []models.Translation
+	if err := db.Where("entity_type = ? AND entity_id IN ? AND language IN ?", entityType, ids, Languages()).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if result[row.EntityID] == nil {
+			result[row.EntityID] = make(Values)
+		}
+		result[row.EntityID].Set(row.Language, row.Field, row.Value)
+	}
+	return result, nil
+}
+
+// Store saves the values of the extra languages of a saved model to the translations table.
+// Empty values remove the stored translation.
+func Store(db *gorm.DB, model any, values Values) error {
+	entityType, id := EntityType(model), EntityID(model)
+	fields := make(map[string]bool)
+	for _, key := range Fields(model) {
+		fields[key] = true
+	}
+	for _, lang := range ExtraLanguages(model) {
+		for key, value := range values[lang] {
+			if !fields[key] {
+				continue
+			}
+			where := db.Where("entity_type = ? AND entity_id = ? AND language = ? AND field = ?", entityType, id, lang, key)
+			if value == "" {
+				if err := where.Delete(&models.Translation{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			row := models.Translation{EntityType: entityType, EntityID: id, Language: lang, Field: key, Value: value, UpdatedAt: time.Now()}
+			err := db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "language"}, {Name: "field"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+			}).Create(&row).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+// Package translation keeps the translatable fields of the models in one place.
+//
+// Languages that have django-modeltranslation columns (TitlePL, TitleEN, ...) are read
+// from and written to those columns, so the existing schema stays readable. Any other
+// enabled language is stored in the translations table keyed by entity, language and field.
+// Translatable fields are marked on the models with a `translate:"<field>"` tag.
+package translation
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	mu              sync.RWMutex
+	languages       = []string{"pl", "en", "uk"}
+	defaultLanguage = "pl"
+)
+
+// Configure sets the enabled languages and the default one.
+// The default language is added to the list if it is missing.
+func Configure(enabled []string, def string) {
+	mu.Lock()
+	defer mu.Unlock()
+	languages = languages[:0:0]
+	seen := make(map[string]bool, len(enabled))
+	for _, lang := range enabled {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == "" || seen[lang] {
+			continue
+		}
+		seen[lang] = true
+		languages = append(languages, lang)
+	}
+	def = strings.ToLower(strings.TrimSpace(def))
+	if def == "" && len(languages) > 0 {
+		def = languages[0]
+	}
+	if !seen[def] {
+		languages = append([]string{def}, languages...)
+	}
+	defaultLanguage = def
+}
+
+// Languages returns the enabled languages.
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]string(nil), languages...)
+}
+
+// Default returns the default language.
+func Default() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLanguage
+}
+
+// Enabled reports whether the language is enabled.
+func Enabled(lang string) bool {
+	for _, enabled := range Languages() {
+		if enabled == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// Values holds translated texts by language and field, e.g. values["de"]["title"].
+type Values map[string]map[string]string
+
+// Get returns the value of a field in a language, or an empty string.
+func (v Values) Get(lang, field string) string {
+	return v[lang][field]
+}
+
+// Set stores the value of a field in a language.
+func (v Values) Set(lang, field, value string) {
+	if v[lang] == nil {
+		v[lang] = make(map[string]string)
+	}
+	v[lang][field] = value
+}
+
+// Merge copies all values of other into v, overwriting existing ones.
+func (v Values) Merge(other Values) {
+	for lang, fields := range other {
+		for field, value := range fields {
+			v.Set(lang, field, value)
+		}
+	}
+}
+
+// field describes a translatable struct field.
+type field struct {
+	key  string // name used in the translations table and forms, e.g. "title"
+	name string // Go field name, e.g. "Title"
+}
+
+var fieldCache sync.Map // reflect.Type -> []field
+
+// structValue returns the struct a model points to.
+func structValue(model any) reflect.Value {
+	v := reflect.ValueOf(model)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	return v
+}
+
+// fieldsOf returns the translatable fields of a struct type.
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if key := f.Tag.Get("translate"); key != "" && f.Type.Kind() == reflect.String {
+			fields = append(fields, field{key: key, name: f.Name})
+		}
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// legacyColumn returns the django-modeltranslation field of a language, e.g. TitleEN.
+func legacyColumn(v reflect.Value, f field, lang string) (reflect.Value, bool) {
+	column := v.FieldByName(f.name + strings.ToUpper(lang))
+	if !column.IsValid() || column.Kind() != reflect.String {
+		return reflect.Value{}, false
+	}
+	return column, true
+}
+
+// Fields returns the keys of the translatable fields of a model, e.g. ["title", "description"].
+func Fields(model any) []string {
+	fields := fieldsOf(structValue(model).Type())
+	keys := make([]string, 0, len(fields))
+	for _, f := range fields {
+		keys = append(keys, f.key)
+	}
+	return keys
+}
+
+// Columns returns the Go names of the struct fields holding translatable texts:
+// the base fields and their translation columns, e.g. Title, TitlePL, TitleEN.
+// Columns of languages that are not enabled are included as well.
+func Columns(model any) []string {
+	t := structValue(model).Type()
+	var names []string
+	for _, f := range fieldsOf(t) {
+		names = append(names, f.name)
+		for i := 0; i < t.NumField(); i++ {
+			suffix, ok := strings.CutPrefix(t.Field(i).Name, f.name)
+			if ok && len(suffix) == 2 && suffix == strings.ToUpper(suffix) {
+				names = append(names, t.Field(i).Name)
+			}
+		}
+	}
+	return names
+}
+
+// HasColumns reports whether the model stores the language in its own columns.
+func HasColumns(model any, lang string) bool {
+	v := structValue(model)
+	fields := fieldsOf(v.Type())
+	if len(fields) == 0 {
+		return false
+	}
+	_, ok := legacyColumn(v, fields[0], lang)
+	return ok
+}
+
+// ExtraLanguages returns the enabled languages the model has no columns for.
+// They are stored in the translations table.
+func ExtraLanguages(model any) []string {
+	var extra []string
+	for _, lang := range Languages() {
+		if !HasColumns(model, lang) {
+			extra = append(extra, lang)
+		}
+	}
+	return extra
+}
+
+// Extract reads the translation columns of the enabled languages from a model.
+// The default language also falls back to the base field, e.g. Title.
+func Extract(model any) Values {
+	v := structValue(model)
+	values := make(Values)
+	for _, f := range fieldsOf(v.Type()) {
+		for _, lang := range Languages() {
+			if column, ok := legacyColumn(v, f, lang); ok {
+				values.Set(lang, f.key, column.String())
+			}
+		}
+		if values.Get(Default(), f.key) == "" {
+			values.Set(Default(), f.key, v.FieldByName(f.name).String())
+		}
+	}
+	return values
+}
+
+// Apply writes the values of languages with translation columns to the model.
+// Values of other languages are ignored, they are saved with Store.
+func Apply(model any, values Values) {
+	v := structValue(model)
+	for _, f := range fieldsOf(v.Type()) {
+		for lang, fields := range values {
+			value, ok := fields[f.key]
+			if !ok {
+				continue
+			}
+			if column, ok := legacyColumn(v, f, lang); ok {
+				column.SetString(value)
+			}
+		}
+	}
+}
+
+// FillDefaults populates empty translation columns with the base field value,
+// e.g. an empty TitleUK gets the value of Title.
+func FillDefaults(model any) {
+	v := structValue(model)
+	for _, f := range fieldsOf(v.Type()) {
+		base := v.FieldByName(f.name).String()
+		for _, lang := range Languages() {
+			if column, ok := legacyColumn(v, f, lang); ok && column.String() == "" {
+				column.SetString(base)
+			}
+		}
+	}
+}
+
+// FromForm reads the fields of the extra languages from a submitted form,
+// using "<field>_<lang>" keys such as "title_de".
+func FromForm(model any, get func(key string) (string, bool)) Values {
+	values := make(Values)
+	for _, lang := range ExtraLanguages(model) {
+		for _, key := range Fields(model) {
+			if value, ok := get(key + "_" + lang); ok {
+				values.Set(lang, key, value)
+			}
+		}
+	}
+	return values
+}
+
+// Only returns the values of the given languages.
+func Only(values Values, langs []string) Values {
+	filtered := make(Values)
+	for _, lang := range langs {
+		if fields, ok := values[lang]; ok {
+			for field, value := range fields {
+				filtered.Set(lang, field, value)
+			}
+		}
+	}
+	return filtered
+}
+
+// EntityType returns the name a model is stored under in the translations table.
+func EntityType(model any) string {
+	return strings.ToLower(structValue(model).Type().Name())
+}
+
+// EntityID returns the primary key of a model.
+func EntityID(model any) uint {
+	id := structValue(model).FieldByName("ID")
+	if !id.IsValid() {
+		return 0
+	}
+	return uint(id.Uint())
+}
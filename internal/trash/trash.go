@@ -0,0 +1,175 @@
+// Package trash restores and permanently deletes soft-deleted content.
+package trash
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/media"
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/translation"
+	"gorm.io/gorm"
+)
+
+// ErrInUse is returned when a record can not be purged because other records still point to it.
+var ErrInUse = errors.New("record is still in use")
+
+// Type is a content type kept in the recycle bin.
+type Type struct {
+	// Name used in the admin URLs, e.g. "programs"
+	Name  string
+	Label string
+	// Column shown as the title of a deleted record
+	TitleColumn string
+	// New returns an empty record of the type
+	New func() any
+	// Images returns the uploaded images of a record, removed when it is purged
+	Images func(record any) []string
+	// Detach clears the rows pointing to a record before it is purged
+	Detach func(tx *gorm.DB, id uint) error
+}
+
+// Item is a deleted record as listed in the recycle bin.
+type Item struct {
+	ID        uint
+	Title     string
+	DeletedAt time.Time
+}
+
+// Types are the content types with a recycle bin.
+var Types = []Type{
+	{
+		Name:        "programs",
+		Label:       "Programs",
+		TitleColumn: "title",
+		New:         func() any { return &models.Program{} },
+		Detach:      detachProgram,
+	},
+	{
+		Name:        "prices",
+		Label:       "Prices",
+		TitleColumn: "item_name",
+		New:         func() any { return &models.Price{} },
+	},
+	{
+		Name:        "news",
+		Label:       "News",
+		TitleColumn: "title",
+		New:         func() any { return &models.News{} },
+		Images: func(record any) []string {
+			news := record.(*models.News)
+			return []string{news.ImageLeft, news.ImageRight}
+		},
+	},
+}
+
+// Find returns the type with the given name.
+func Find(name string) (Type, bool) {
+	for _, t := range Types {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Type{}, false
+}
+
+// List returns the deleted records of a type, most recently deleted first.
+func List(db *gorm.DB, t Type) ([]Item, error) {
+	var items []Item
+	err := db.Unscoped().Model(t.New()).
+		Select("id", t.TitleColumn+" AS title", "deleted_at").
+		Where("deleted_at IS NOT NULL").Order("deleted_at desc").Scan(&items).Error
+	return items, err
+}
+
+// FindDeleted loads a deleted record of a type.
+func FindDeleted(db *gorm.DB, t Type, id any) (any, error) {
+	record := t.New()
+	err := db.Unscoped().Where("deleted_at IS NOT NULL").First(record, id).Error
+	return record, err
+}
+
+// Restore brings a deleted record back.
+func Restore(db *gorm.DB, record any) error {
+	return db.Unscoped().Model(record).Update("deleted_at", nil).Error
+}
+
+// Purge permanently deletes a record with its translations and revisions,
+// then releases its uploaded images and those of its revisions, see media.Release.
+// Audit entries are kept.
+func Purge(db *gorm.DB, t Type, record any) error {
+	entityType, id := translation.EntityType(record), translation.EntityID(record)
+	var images []string
+	if t.Images != nil {
+		var err error
+		if images, err = media.RevisionPaths(db, record); err != nil {
+			return err
+		}
+		images = append(images, t.Images(record)...)
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if t.Detach != nil {
+			if err := t.Detach(tx, id); err != nil {
+				return err
+			}
+		}
+		for _, model := range []any{&models.Translation{}, &models.Revision{}} {
+			if err := tx.Where("entity_type = ? AND entity_id = ?", entityType, id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(record).Error
+	})
+	if err != nil || t.Images == nil {
+		return err
+	}
+	// The record is gone already, a leftover file is only logged.
+	// Images reused by other records or pinned to the media library stay.
+	if err := media.Release(db, images...); err != nil {
+		log.Printf("Failed to remove images of %s %d: %s", entityType, id, err)
+	}
+	return nil
+}
+
+// PurgeOlderThan permanently deletes the records of every type deleted before the given time.
+// Records still in use are skipped. It returns the number of purged records.
+func PurgeOlderThan(db *gorm.DB, before time.Time) (int, error) {
+	purged := 0
+	for _, t := range Types {
+		var ids []uint
+		if err := db.Unscoped().Model(t.New()).Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+			return purged, err
+		}
+		for _, id := range ids {
+			record, err := FindDeleted(db, t, id)
+			if err != nil {
+				return purged, err
+			}
+			if err := Purge(db, t, record); err != nil {
+				if errors.Is(err, ErrInUse) {
+					continue
+				}
+				return purged, err
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// detachProgram unlinks the prices and specialists of a program. Programs with
+// appointments are kept, as the appointments still refer to them.
+func detachProgram(tx *gorm.DB, id uint) error {
+	var appointments int64
+	if err := tx.Unscoped().Model(&models.Appointment{}).Where("program_id = ?", id).Count(&appointments).Error; err != nil {
+		return err
+	}
+	if appointments > 0 {
+		return ErrInUse
+	}
+	if err := tx.Unscoped().Model(&models.Price{}).Where("program_id = ?", id).Update("program_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Exec("DELETE FROM specialist_programs WHERE program_id = ?", id).Error
+}
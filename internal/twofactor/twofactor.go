@@ -0,0 +1,276 @@
+// Package twofactor adds a second login step to the admin panel: a code of an
+// authenticator app (TOTP, RFC 6238) or one of the recovery codes of the user.
+package twofactor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"image/png"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/DmytroPI-dev/clinic-golang/internal/utils"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+// Issuer names the site in the authenticator apps.
+const Issuer = "Clinic Admin"
+
+// RecoveryCodeCount is the number of recovery codes a user gets.
+const RecoveryCodeCount = 10
+
+// period is the lifetime of a code in seconds, the default of the authenticator apps.
+const period = 30
+
+// ErrInvalidCode is returned for a wrong, expired or already used code.
+var ErrInvalidCode = errors.New("invalid code")
+
+// ErrNoPendingKey is returned when a user confirms a key that was never shown.
+var ErrNoPendingKey = errors.New("no key is being enrolled")
+
+// totpCode matches a code of an authenticator app, recovery codes are longer.
+var totpCode = regexp.MustCompile(`^[0-9]{6}$`)
+
+// NewKey makes a new secret for a user, to be added to an authenticator app.
+func NewKey(account string) (*otp.Key, error) {
+	return totp.Generate(totp.GenerateOpts{Issuer: Issuer, AccountName: account, Period: period})
+}
+
+// Begin makes a new key for a user to add to an authenticator app. Its secret is kept
+// with the user, not in the browser, until a code of it is entered, see Enable.
+// A new key replaces the one pending before.
+func Begin(db *gorm.DB, user *models.User) (*otp.Key, error) {
+	key, err := NewKey(user.UserName)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Model(user).Update("totp_pending_secret", key.Secret()).Error; err != nil {
+		return nil, err
+	}
+	user.TOTPPendingSecret = key.Secret()
+	return key, nil
+}
+
+// PendingKey returns the key a user is enrolling, made by Begin.
+func PendingKey(user models.User) (*otp.Key, error) {
+	if user.TOTPPendingSecret == "" {
+		return nil, ErrNoPendingKey
+	}
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(user.TOTPPendingSecret)
+	if err != nil {
+		return nil, err
+	}
+	return totp.Generate(totp.GenerateOpts{Issuer: Issuer, AccountName: user.UserName, Period: period, Secret: secret})
+}
+
+// Enrollment returns the key a user is enrolling, beginning one when there is none.
+// Showing the page again keeps the key, so the QR code being scanned stays valid.
+func Enrollment(db *gorm.DB, user *models.User) (*otp.Key, error) {
+	key, err := PendingKey(*user)
+	if errors.Is(err, ErrNoPendingKey) {
+		return Begin(db, user)
+	}
+	return key, err
+}
+
+// QRCode returns the QR code of a key as a data URL for an img tag.
+func QRCode(key *otp.Key) (template.URL, error) {
+	img, err := key.Image(200, 200)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+// step returns the time step of a code that is valid at the given time, allowing
+// one step of clock drift either way. It returns ErrInvalidCode when there is none.
+func step(secret, code string, now time.Time) (int64, error) {
+	code = strings.TrimSpace(code)
+	if !totpCode.MatchString(code) {
+		return 0, ErrInvalidCode
+	}
+	current := now.Unix() / period
+	for _, s := range []int64{current, current - 1, current + 1} {
+		valid, err := totp.ValidateCustom(code, secret, time.Unix(s*period, 0), totp.ValidateOpts{
+			Period:    period,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if valid {
+			return s, nil
+		}
+	}
+	return 0, ErrInvalidCode
+}
+
+// CheckKey checks the first code of a key that is being enrolled. It returns the
+// time step of the code, so that it can not be used again to log in.
+func CheckKey(key *otp.Key, code string, now time.Time) (int64, error) {
+	return step(key.Secret(), code, now)
+}
+
+// Verify checks the second login step of a user, a code of the authenticator app
+// or an unused recovery code. Every code is accepted only once.
+func Verify(db *gorm.DB, user *models.User, code string, now time.Time) error {
+	if !user.HasTwoFactor() {
+		return ErrInvalidCode
+	}
+	if !totpCode.MatchString(strings.TrimSpace(code)) {
+		return useRecoveryCode(db, user, code, now)
+	}
+	s, err := step(user.TOTPSecret, code, now)
+	if err != nil {
+		return err
+	}
+	// The condition keeps two logins with the same code from both succeeding
+	used := db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, s).
+		Update("totp_last_step", s)
+	if used.Error != nil {
+		return used.Error
+	}
+	if used.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	user.TOTPLastStep = s
+	return nil
+}
+
+// useRecoveryCode marks a recovery code of a user as used.
+func useRecoveryCode(db *gorm.DB, user *models.User, code string, now time.Time) error {
+	used := db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(normalize(code))).
+		Update("used_at", now)
+	if used.Error != nil {
+		return used.Error
+	}
+	if used.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// Enable turns on two-factor login for a user with an enrolled key. firstStep is
+// the step of the code that confirmed the key. It returns the plain recovery codes.
+func Enable(db *gorm.DB, user *models.User, key *otp.Key, firstStep int64, now time.Time) ([]string, error) {
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(user).Updates(map[string]any{
+			"totp_secret":         key.Secret(),
+			"totp_last_step":      firstStep,
+			"totp_enabled_at":     now,
+			"totp_pending_secret": "",
+		}).Error
+		if err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = key.Secret()
+	user.TOTPLastStep = firstStep
+	user.TOTPEnabledAt = &now
+	user.TOTPPendingSecret = ""
+	return codes, nil
+}
+
+// Disable turns off two-factor login for a user and removes the recovery codes,
+// e.g. when the user lost the phone and the recovery codes.
+func Disable(db *gorm.DB, user *models.User) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(user).Updates(map[string]any{
+			"totp_secret":     "",
+			"totp_last_step":  0,
+			"totp_enabled_at": nil,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.TOTPEnabledAt = nil
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user, the old ones stop working.
+func RegenerateRecoveryCodes(db *gorm.DB, user *models.User) ([]string, error) {
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// RemainingRecoveryCodes counts the unused recovery codes of a user.
+func RemainingRecoveryCodes(db *gorm.DB, userID uint) (int64, error) {
+	var count int64
+	err := db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// replaceRecoveryCodes deletes the recovery codes of a user and makes new ones.
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	plain := make([]string, 0, RecoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, RecoveryCodeCount)
+	for range RecoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		plain = append(plain, code)
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(normalize(code))})
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return plain, nil
+}
+
+// newRecoveryCode returns a random code such as "k7xq-2mdp-a9fe-wr3c", 80 bits in
+// letters and digits that are easy to type.
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16], nil
+}
+
+// normalize drops the dashes, spaces and capitals a user may type in a recovery code.
+func normalize(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
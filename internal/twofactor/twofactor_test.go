@@ -0,0 +1,218 @@
+package twofactor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/database"
+	"github.com/DmytroPI-dev/clinic-golang/internal/migrations"
+	"github.com/DmytroPI-dev/clinic-golang/internal/models"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+// newTestDB returns an in-memory SQLite database with every migration applied.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.DB_Connect(database.DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.New(db).Up(0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// code returns the code of an authenticator app for a secret at the given time.
+func code(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := totp.GenerateCode(secret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enrolledUser saves a user and enables two-factor login, returning the recovery codes.
+func enrolledUser(t *testing.T, db *gorm.DB, now time.Time) (models.User, []string) {
+	t.Helper()
+	user := models.User{UserName: "alice", Email: "alice@example.com", Role: models.Admin}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Begin(db, &user); err != nil {
+		t.Fatal(err)
+	}
+	key, err := PendingKey(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstStep, err := CheckKey(key, code(t, key.Secret(), now), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := Enable(db, &user, key, firstStep, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, codes
+}
+
+func TestEnableKeepsConfirmedKey(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	user, codes := enrolledUser(t, db, now)
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), RecoveryCodeCount)
+	}
+	var stored models.User
+	if err := db.First(&stored, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !stored.HasTwoFactor() || stored.TOTPSecret == "" || stored.TOTPPendingSecret != "" {
+		t.Fatalf("got enabled %v, secret %q, pending %q", stored.HasTwoFactor(), stored.TOTPSecret, stored.TOTPPendingSecret)
+	}
+	if _, err := PendingKey(stored); !errors.Is(err, ErrNoPendingKey) {
+		t.Fatalf("got %v, want ErrNoPendingKey after enabling", err)
+	}
+}
+
+func TestVerifyRejectsReplayedCode(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	user, _ := enrolledUser(t, db, now.Add(-time.Hour))
+
+	current := code(t, user.TOTPSecret, now)
+	if err := Verify(db, &user, current, now); err != nil {
+		t.Fatalf("first use of the code: %v", err)
+	}
+	// A second login with the same code, also with another copy of the user
+	if err := Verify(db, &user, current, now); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("got %v, want ErrInvalidCode for a replayed code", err)
+	}
+	var stale models.User
+	if err := db.First(&stale, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	stale.TOTPLastStep = 0
+	if err := Verify(db, &stale, current, now); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("got %v, want ErrInvalidCode for a replayed code", err)
+	}
+	// An older code is refused too, the next one is accepted
+	if err := Verify(db, &user, code(t, user.TOTPSecret, now.Add(-period*time.Second)), now); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("got %v, want ErrInvalidCode for an older code", err)
+	}
+	next := now.Add(period * time.Second)
+	if err := Verify(db, &user, code(t, user.TOTPSecret, next), next); err != nil {
+		t.Fatalf("next code: %v", err)
+	}
+}
+
+func TestVerifyAllowsClockDrift(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	user, _ := enrolledUser(t, db, now.Add(-time.Hour))
+
+	if err := Verify(db, &user, code(t, user.TOTPSecret, now.Add(-period*time.Second)), now); err != nil {
+		t.Fatalf("code of the previous step: %v", err)
+	}
+	if err := Verify(db, &user, code(t, user.TOTPSecret, now.Add(-3*period*time.Second)), now); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("got %v, want ErrInvalidCode for an expired code", err)
+	}
+}
+
+func TestRecoveryCodesWorkOnce(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	user, codes := enrolledUser(t, db, now)
+
+	// Typed in capitals and without the dashes
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))
+	if err := Verify(db, &user, typed, now); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if err := Verify(db, &user, codes[0], now); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("got %v, want ErrInvalidCode for a used recovery code", err)
+	}
+	remaining, err := RemainingRecoveryCodes(db, user.ID)
+	if err != nil || remaining != RecoveryCodeCount-1 {
+		t.Fatalf("got %d remaining codes, %v", remaining, err)
+	}
+	if err := Verify(db, &user, "aaaa-bbbb-cccc-dddd", now); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("got %v, want ErrInvalidCode for an unknown recovery code", err)
+	}
+}
+
+func TestRegenerateRecoveryCodesReplacesOldOnes(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	user, old := enrolledUser(t, db, now)
+
+	codes, err := RegenerateRecoveryCodes(db, &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(db, &user, old[1], now); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("got %v, want ErrInvalidCode for a replaced recovery code", err)
+	}
+	if err := Verify(db, &user, codes[1], now); err != nil {
+		t.Fatalf("new recovery code: %v", err)
+	}
+}
+
+func TestDisableRemovesCodes(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	user, codes := enrolledUser(t, db, now)
+
+	if err := Disable(db, &user); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(db, &user, codes[0], now); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("got %v, want ErrInvalidCode without two-factor login", err)
+	}
+	remaining, err := RemainingRecoveryCodes(db, user.ID)
+	if err != nil || remaining != 0 {
+		t.Fatalf("got %d remaining codes, %v after disabling", remaining, err)
+	}
+}
+
+func TestEnrollmentKeepsPendingKey(t *testing.T) {
+	db := newTestDB(t)
+	user := models.User{UserName: "alice", Email: "alice@example.com", Role: models.Admin}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	first, err := Enrollment(db, &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another page load, e.g. in a second tab
+	var reloaded models.User
+	if err := db.First(&reloaded, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	again, err := Enrollment(db, &reloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Secret() != first.Secret() {
+		t.Fatal("showing the page again replaced the pending key")
+	}
+	// Starting over makes a new key
+	restarted, err := Begin(db, &reloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restarted.Secret() == first.Secret() {
+		t.Fatal("Begin kept the pending key")
+	}
+}
@@ -1,42 +1,358 @@
 package utils
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
 	"github.com/disintegration/imaging"
+	"github.com/gen2brain/webp"
+	"image"
+	"io"
+	"log"
+	"mime"
 	"mime/multipart"
+	"net/http"
 	"path/filepath"
-	"time"
+	"strings"
+
+	// Decodes WebP uploads
+	_ "golang.org/x/image/webp"
 )
 
-// ProcessAndSaveImage handles uploading, resizing, and saving an image.
-// It returns the public path to the saved file or an error.
-func ProcessAndSaveImages(file *multipart.FileHeader) (string, error) {
-	// Open the uploaded file
+// maxImageWidth is the width of the main file of an upload. Smaller images are not enlarged.
+const maxImageWidth = 800
+
+// imageWidths are the widths of the resized variants made of every upload, see ConfigureImages.
+var imageWidths = []int{160, 400, 800, 1600}
+
+// ConfigureImages sets the widths of the resized variants made of every upload.
+// The smallest one is used as the thumbnail.
+func ConfigureImages(widths []int) {
+	imageWidths = widths
+}
+
+// webpQuality is the quality of the WebP copies, see ConfigureWebP.
+var webpQuality = 80
+
+// ConfigureWebP sets the quality of the WebP copies made of every upload, 1 to 100.
+// The copies are lossy, which suits photos, 100 makes them lossless.
+func ConfigureWebP(quality int) {
+	webpQuality = quality
+}
+
+// Limits of the uploaded images, see ConfigureImageLimits.
+var (
+	maxUploadBytes int64 = 10 << 20
+	maxImagePixels       = 40_000_000
+)
+
+// ConfigureImageLimits sets the largest accepted upload, in bytes and in pixels.
+// The pixel limit keeps small files that decode to huge images out.
+func ConfigureImageLimits(maxBytes int64, maxPixels int) {
+	maxUploadBytes, maxImagePixels = maxBytes, maxPixels
+}
+
+// uploadFormats are the accepted content types of uploads, sniffed from the file itself,
+// with the format their main file is saved in and its extension.
+var uploadFormats = map[string]struct {
+	format imaging.Format
+	ext    string
+}{
+	"image/jpeg": {imaging.JPEG, ".jpg"},
+	"image/png":  {imaging.PNG, ".png"},
+	"image/gif":  {imaging.GIF, ".gif"},
+	// WebP can not be written by imaging, the WebP copies are made next to the PNG
+	"image/webp": {imaging.PNG, ".png"},
+}
+
+// ImageError is an upload rejected by the validation. Its message can be shown to the user.
+type ImageError struct {
+	Reason string
+}
+
+func (e *ImageError) Error() string {
+	return e.Reason
+}
+
+// ImageVariant is a resized copy of an upload, in its original format or in WebP.
+type ImageVariant struct {
+	// Public path of the file, e.g. "/uploads/3a7bd3e2360a3d29eea436fcfb7e44c7-400w.webp"
+	Path string
+	// Image format, e.g. "jpeg", "png" or "webp"
+	Format string
+	Width  int
+	Height int
+	// File size in bytes
+	Size int64
+}
+
+// SavedImage describes an image written to the upload storage.
+type SavedImage struct {
+	// Public path of the file, e.g. "/uploads/3a7bd3e2360a3d29eea436fcfb7e44c7.jpg"
+	Path   string
+	Width  int
+	Height int
+	// File size in bytes
+	Size int64
+	// Resized copies, the main file and its WebP copy included
+	Variants []ImageVariant
+	// SHA-256 of the uploaded file, in hex, see UploadHash
+	Hash string
+}
+
+// readUpload reads an uploaded file, rejecting it with an *ImageError when it is over the size limit.
+func readUpload(file *multipart.FileHeader) ([]byte, error) {
+	if file.Size > maxUploadBytes {
+		return nil, &ImageError{fmt.Sprintf("The image is larger than %d MB", maxUploadBytes>>20)}
+	}
 	src, err := file.Open()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer src.Close()
+	content, err := io.ReadAll(io.LimitReader(src, maxUploadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxUploadBytes {
+		return nil, &ImageError{fmt.Sprintf("The image is larger than %d MB", maxUploadBytes>>20)}
+	}
+	return content, nil
+}
 
-	// Decode image
-	img, err := imaging.Decode(src)
+// UploadHash returns the SHA-256 of an uploaded file, in hex. Uploads of the same
+// file have the same hash, whatever their names, see ProcessAndSaveImages.
+func UploadHash(file *multipart.FileHeader) (string, error) {
+	content, err := readUpload(file)
 	if err != nil {
 		return "", err
 	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ProcessAndSaveImage handles uploading, resizing, and saving an image.
+// It returns the public path, the size and the variants of the saved file or an error.
+// Uploads over the size limits or of another type than JPEG, PNG, GIF or WebP are
+// rejected with an *ImageError. Only the pixels are saved, turned upright as the
+// EXIF orientation says. The metadata, e.g. the GPS position of a photo, is dropped.
+//
+// Files are named after the hash of the upload, e.g. "/uploads/3a7bd3e2360a3d29eea436fcfb7e44c7.jpg",
+// so saving the same file again writes the same files.
+func ProcessAndSaveImages(file *multipart.FileHeader) (SavedImage, error) {
+	content, err := readUpload(file)
+	if err != nil {
+		return SavedImage{}, err
+	}
 
-	// Resizing image to max width 800px, preserving aspect ratio
-	resized := imaging.Resize(img, 800, 0, imaging.Lanczos)
+	// The type is sniffed from the content, the name and the header can not be trusted
+	upload, ok := uploadFormats[http.DetectContentType(content)]
+	if !ok {
+		return SavedImage{}, &ImageError{"Only JPEG, PNG, GIF and WebP images can be uploaded"}
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return SavedImage{}, &ImageError{"The file is not a valid image"}
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return SavedImage{}, &ImageError{fmt.Sprintf("The image is %d×%d pixels, more than %d megapixels",
+			config.Width, config.Height, maxImagePixels/1_000_000)}
+	}
 
-	// Create unique name
-	uniqueFileName := fmt.Sprintf("%d%s", time.Now().Unix(), filepath.Base(file.Filename))
-	savePath := filepath.Join("uploads", uniqueFileName)
+	// Decode image, the encoders write none of the metadata back
+	img, err := imaging.Decode(bytes.NewReader(content), imaging.AutoOrientation(true))
+	if err != nil {
+		return SavedImage{}, &ImageError{"The file is not a valid image"}
+	}
 
-	// Save resized image
-	err = imaging.Save(resized, savePath)
+	// Name the file after its content, 128 bits of the hash are plenty
+	sum := sha256.Sum256(content)
+	saved, err := saveImage(img, hex.EncodeToString(sum[:16])+upload.ext)
+	saved.Hash = hex.EncodeToString(sum[:])
+	return saved, err
+}
+
+// saveImage writes the main file of an upload, resized to maxImageWidth, and its variants.
+func saveImage(img image.Image, name string) (SavedImage, error) {
+	main := img
+	if img.Bounds().Dx() > maxImageWidth {
+		// Resizing image to max width 800px, preserving aspect ratio
+		main = imaging.Resize(img, maxImageWidth, 0, imaging.Lanczos)
+	}
+	format, err := imaging.FormatFromFilename(name)
 	if err != nil {
-		return "", err
+		return SavedImage{}, err
+	}
+	saved, err := putImage(main, name, format)
+	if err != nil {
+		return SavedImage{}, err
+	}
+	variants, err := saveVariants(img, saved)
+	return SavedImage{
+		Path:     saved.Path,
+		Width:    saved.Width,
+		Height:   saved.Height,
+		Size:     saved.Size,
+		Variants: variants,
+	}, err
+}
+
+// saveVariants writes the resized copies of an image next to its main file, in the
+// original format and in WebP, e.g. photo-400w.jpg and photo-400w.webp. The main file
+// is listed as a variant too, next to its WebP copy photo.webp. Widths larger than
+// the image itself are skipped, and so are WebP copies larger than the original format.
+func saveVariants(img image.Image, main ImageVariant) ([]ImageVariant, error) {
+	name, err := storage.Name(main.Path)
+	if err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	format, err := imaging.FormatFromFilename(name)
+	if err != nil {
+		return nil, err
+	}
+	variants := []ImageVariant{main}
+	if variants, err = addWebP(variants, img, stem+".webp", main); err != nil {
+		return variants, err
+	}
+
+	for _, width := range imageWidths {
+		if width == main.Width || width > img.Bounds().Dx() {
+			continue
+		}
+		resized := imaging.Resize(img, width, 0, imaging.Lanczos)
+		name := fmt.Sprintf("%s-%dw", stem, width)
+		original, err := putImage(resized, name+ext, format)
+		if err != nil {
+			return variants, err
+		}
+		variants = append(variants, original)
+		if variants, err = addWebP(variants, resized, name+".webp", original); err != nil {
+			return variants, err
+		}
 	}
+	return variants, nil
+}
 
-	return "/" + savePath, nil
+// addWebP writes the WebP copy of a variant at the configured quality and adds it to
+// the variants. A copy that is not smaller than the variant in the original format is
+// of no use to the browsers and is left out, which is logged.
+func addWebP(variants []ImageVariant, img image.Image, name string, original ImageVariant) ([]ImageVariant, error) {
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, img, webp.Options{Quality: webpQuality}); err != nil {
+		return variants, err
+	}
+	if int64(buf.Len()) >= original.Size {
+		log.Printf("Skipped WebP copy %s, %d bytes at quality %d against %d bytes of %s",
+			name, buf.Len(), webpQuality, original.Size, original.Path)
+		return variants, nil
+	}
+	webp, err := putFile(img, name, "webp", buf.Bytes())
+	if err != nil {
+		return variants, err
+	}
+	return append(variants, webp), nil
+}
+
+// putImage encodes an image in the given format and writes it to the upload storage.
+func putImage(img image.Image, name string, format imaging.Format) (ImageVariant, error) {
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, format); err != nil {
+		return ImageVariant{}, err
+	}
+	return putFile(img, name, strings.ToLower(format.String()), buf.Bytes())
+}
+
+// putFile writes an encoded image to the upload storage.
+func putFile(img image.Image, name, format string, content []byte) (ImageVariant, error) {
+	err := storage.Current().Put(name, bytes.NewReader(content), int64(len(content)), mime.TypeByExtension(filepath.Ext(name)))
+	if err != nil {
+		return ImageVariant{}, err
+	}
+	return ImageVariant{
+		Path:   storage.Path(name),
+		Format: format,
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+		Size:   int64(len(content)),
+	}, nil
+}
 
+// openImage reads and decodes a saved upload, given its public path. It returns the file size too.
+func openImage(publicPath string) (image.Image, int64, error) {
+	name, err := storage.Name(publicPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	file, err := storage.Current().Open(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	img, err := imaging.Decode(bytes.NewReader(content))
+	return img, int64(len(content)), err
+}
+
+// RegenerateImage processes a saved upload again, given its public path such as
+// "/uploads/1700000000photo.jpg", and makes its variants again, e.g. after the widths
+// changed. Main files within the size limit are left untouched. Variants wider than
+// the main file can not be made again.
+func RegenerateImage(publicPath string) (SavedImage, error) {
+	img, size, err := openImage(publicPath)
+	if err != nil {
+		return SavedImage{}, err
+	}
+	if img.Bounds().Dx() > maxImageWidth {
+		name, _ := storage.Name(publicPath)
+		return saveImage(img, name)
+	}
+	format, err := imaging.FormatFromFilename(publicPath)
+	if err != nil {
+		return SavedImage{}, err
+	}
+	main := ImageVariant{
+		Path:   publicPath,
+		Format: strings.ToLower(format.String()),
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+		Size:   size,
+	}
+	variants, err := saveVariants(img, main)
+	return SavedImage{
+		Path:     main.Path,
+		Width:    main.Width,
+		Height:   main.Height,
+		Size:     main.Size,
+		Variants: variants,
+	}, err
+}
+
+// ImageInfo returns the dimensions and size of a saved upload, given its public path.
+func ImageInfo(publicPath string) (SavedImage, error) {
+	img, size, err := openImage(publicPath)
+	if err != nil {
+		return SavedImage{}, err
+	}
+	return SavedImage{
+		Path:   publicPath,
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+		Size:   size,
+	}, nil
+}
+
+// RemoveImage deletes a saved upload, given its public path. A missing file is not an error.
+func RemoveImage(publicPath string) error {
+	name, err := storage.Name(publicPath)
+	if err != nil {
+		return err
+	}
+	return storage.Current().Delete(name)
 }
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/DmytroPI-dev/clinic-golang/internal/storage"
+)
+
+// photo returns an image with smooth gradients and noise, which compresses like a photo.
+func photo(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	noise := rand.New(rand.NewSource(1))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{
+				R: uint8(x * 255 / width),
+				G: uint8(y * 255 / height),
+				B: uint8(128 + noise.Intn(32)),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+func TestSaveImageMakesWebPCopiesOfPhotos(t *testing.T) {
+	previous := storage.Current()
+	t.Cleanup(func() { storage.Configure(previous) })
+	storage.Configure(storage.NewLocal(t.TempDir(), "/uploads"))
+	defer func(widths []int) { imageWidths = widths }(imageWidths)
+	imageWidths = []int{160, 400}
+
+	saved, err := saveImage(photo(600, 400), "photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := map[int]map[string]int64{}
+	for _, variant := range saved.Variants {
+		if sizes[variant.Width] == nil {
+			sizes[variant.Width] = map[string]int64{}
+		}
+		sizes[variant.Width][variant.Format] = variant.Size
+	}
+	for _, width := range []int{160, 400, 600} {
+		jpeg, webp := sizes[width]["jpeg"], sizes[width]["webp"]
+		if jpeg == 0 || webp == 0 {
+			t.Fatalf("width %d has JPEG %d and WebP %d bytes, want both", width, jpeg, webp)
+		}
+		if webp >= jpeg {
+			t.Errorf("width %d: WebP copy of %d bytes is not smaller than the JPEG of %d", width, webp, jpeg)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// TokenPrefix marks the tokens issued by this application.
+const TokenPrefix = "clk_"
+
+// GenerateToken creates a new random API token.
+// It returns the plain token, which is shown to the user only once, and its hash for storage.
+func GenerateToken() (plain string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	plain = TokenPrefix + hex.EncodeToString(buf)
+	return plain, HashToken(plain), nil
+}
+
+// HashToken returns the hex encoded SHA-256 hash of a token.
+func HashToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
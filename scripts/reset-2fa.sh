@@ -0,0 +1,12 @@
+#!/bin/sh
+# Resets the two-factor login of an admin panel user who lost the authenticator
+# app and the recovery codes. Run it from the repository root, next to .env:
+#
+#   scripts/reset-2fa.sh USERNAME
+set -e
+
+if [ -z "$1" ]; then
+    echo "usage: $0 USERNAME" >&2
+    exit 2
+fi
+exec go run ./cmd/clinicctl user reset-2fa -user "$1"